@@ -0,0 +1,279 @@
+package keyvalstore
+
+import "time"
+
+// GetOrSet returns the live value stored under key and true. If the key is
+// missing or expired, it stores value with the given ttl instead and returns
+// value and false. The check and the store happen atomically, so concurrent
+// callers agree on a single stored value. If the cache is closed, or value
+// exceeds the cache's maximum cost or size, value is not stored but is still
+// returned with false; use SetNX to learn whether a value was stored.
+func (c *Cache[K, V]) GetOrSet(key K, value V, ttl time.Duration) (V, bool) {
+	now := c.clock.Now()
+	item, err := c.newItem(key, value, ttl, c.expiryAt(now, ttl))
+
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	if existing, exists := s.data[key]; exists && existing.live(now) {
+		if s.policy != nil {
+			s.policy.access(key)
+		}
+		s.mutex.Unlock()
+		return c.copyValue(existing.value), true
+	}
+	var evicted []evictedEntry[K, V]
+	if err == nil {
+		evicted = c.storeLocked(s, key, item)
+	}
+	s.mutex.Unlock()
+
+	c.notifyEvicted(evicted)
+	return value, false
+}
+
+// SetNX stores value under key with the given ttl only if no live value exists,
+// returning true if it did. An expired entry counts as absent and is replaced.
+func (c *Cache[K, V]) SetNX(key K, value V, ttl time.Duration) bool {
+	now := c.clock.Now()
+	item, err := c.newItem(key, value, ttl, c.expiryAt(now, ttl))
+	if err != nil {
+		return false
+	}
+
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	if existing, exists := s.data[key]; exists && existing.live(now) {
+		s.mutex.Unlock()
+		return false
+	}
+	evicted := c.storeLocked(s, key, item)
+	s.mutex.Unlock()
+
+	c.notifyEvicted(evicted)
+	return true
+}
+
+// Replace stores value under key with a fresh ttl only if a live value already
+// exists, returning true if it did. Missing and expired keys are left alone.
+func (c *Cache[K, V]) Replace(key K, value V, ttl time.Duration) bool {
+	now := c.clock.Now()
+	item, err := c.newItem(key, value, ttl, c.expiryAt(now, ttl))
+	if err != nil {
+		return false
+	}
+
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	if existing, exists := s.data[key]; !exists || !existing.live(now) {
+		s.mutex.Unlock()
+		return false
+	}
+	evicted := c.storeLocked(s, key, item)
+	s.mutex.Unlock()
+
+	c.notifyEvicted(evicted)
+	return true
+}
+
+// Swap stores value under key with the given ttl and returns the live value it
+// replaced and true, or the zero value and false if the key was missing or
+// expired. The read and the store happen atomically. If the cache is closed,
+// or value exceeds the cache's maximum cost or size, value is not stored and
+// the current value is left in place, but Swap still returns it as though it
+// had been replaced; use Store to learn whether a value was stored.
+func (c *Cache[K, V]) Swap(key K, value V, ttl time.Duration) (V, bool) {
+	now := c.clock.Now()
+	item, err := c.newItem(key, value, ttl, c.expiryAt(now, ttl))
+
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	old, exists := s.data[key]
+	found := exists && old.live(now)
+	var evicted []evictedEntry[K, V]
+	if err == nil {
+		evicted = c.storeLocked(s, key, item)
+	}
+	s.mutex.Unlock()
+
+	c.notifyEvicted(evicted)
+	if !found {
+		var zero V
+		return zero, false
+	}
+	return old.value, true
+}
+
+// GetAndDelete removes key and returns its live value and true, so no other
+// caller can observe the value afterwards. It returns the zero value and false
+// if the key is missing or expired. The eviction callback, if any, receives
+// ReasonDeleted.
+func (c *Cache[K, V]) GetAndDelete(key K) (V, bool) {
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	item, exists := s.data[key]
+	if !exists || !item.live(c.clock.Now()) {
+		s.mutex.Unlock()
+		var zero V
+		return zero, false
+	}
+	s.removeLocked(key)
+	removed := c.removedLocked(nil, key, item.value, ReasonDeleted)
+	s.mutex.Unlock()
+
+	c.notifyEvicted(removed)
+	return item.value, true
+}
+
+// CompareAndSwap stores new under key with a fresh ttl if the live value
+// currently stored equals old, returning true if it did. It returns false if
+// the values differ or the key is missing or expired. It is a function rather
+// than a method because it requires a comparable value type.
+func CompareAndSwap[K comparable, V comparable](c *Cache[K, V], key K, old, new V, ttl time.Duration) bool {
+	now := c.clock.Now()
+	item, err := c.newItem(key, new, ttl, c.expiryAt(now, ttl))
+	if err != nil {
+		return false
+	}
+
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	if existing, exists := s.data[key]; !exists || !existing.live(now) || existing.value != old {
+		s.mutex.Unlock()
+		return false
+	}
+	evicted := c.storeLocked(s, key, item)
+	s.mutex.Unlock()
+
+	c.notifyEvicted(evicted)
+	return true
+}
+
+// SetIfNewer stores value under key with the given ttl only if version is
+// greater than the version of the live value stored there, returning true if
+// it did. A missing or expired key is always stored, and a value stored by
+// Set and the other setters has version zero. Use it to apply updates that
+// may arrive out of order without letting a stale one overwrite a newer one.
+// It returns false if value is rejected by the cache's cost or size limit.
+func (c *Cache[K, V]) SetIfNewer(key K, value V, version int64, ttl time.Duration) bool {
+	now := c.clock.Now()
+	item, err := c.newItem(key, value, ttl, c.expiryAt(now, ttl))
+	if err != nil {
+		return false
+	}
+	item.version = version
+
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	if existing, exists := s.data[key]; exists && existing.live(now) && existing.version >= version {
+		s.mutex.Unlock()
+		return false
+	}
+	evicted := c.storeLocked(s, key, item)
+	s.mutex.Unlock()
+
+	c.notifyEvicted(evicted)
+	return true
+}
+
+// Number is the set of value types supported by Increment.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// Increment atomically adds delta to the live value stored under key and
+// returns the result. A missing or expired key is created with value delta and
+// the given ttl; an existing key keeps its expiry, tags, metadata and version.
+// Use a negative delta to decrement. It returns ErrItemTooLarge if the new
+// value is rejected by the cache's cost or size limit, and ErrClosed if the
+// cache is closed.
+func Increment[K comparable, V Number](c *Cache[K, V], key K, delta V, ttl time.Duration) (V, error) {
+	now := c.clock.Now()
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	item, exists := s.data[key]
+	if exists && item.live(now) {
+		item.value += delta
+	} else {
+		item = cacheItem[V]{value: delta, expiryTime: c.expiryAt(now, ttl), ttl: ttl}
+	}
+	tags, meta, version := item.tags, item.meta, item.version
+	item, err := c.newItem(key, item.value, item.ttl, item.expiryTime)
+	item.tags, item.meta, item.version = tags, meta, version
+	if err != nil {
+		s.mutex.Unlock()
+		var zero V
+		return zero, err
+	}
+	evicted := c.storeLocked(s, key, item)
+	s.mutex.Unlock()
+
+	c.notifyEvicted(evicted)
+	return item.value, nil
+}
+
+// callUnlockingOnPanic calls fn, which runs with s locked for writing, and
+// releases the lock before a panic in fn propagates, so that it does not leave
+// the shard locked forever.
+func callUnlockingOnPanic[K comparable, V any](s *shard[K, V], fn func() (V, bool)) (V, bool) {
+	completed := false
+	defer func() {
+		if !completed {
+			s.mutex.Unlock()
+		}
+	}()
+	value, keep := fn()
+	completed = true
+	return value, keep
+}
+
+// Update atomically replaces the value stored under key with the result of fn,
+// which receives the current live value and true, or the zero value and false
+// if the key is missing or expired. If fn returns keep as false the key is
+// deleted instead, and the eviction callback, if any, receives ReasonDeleted.
+// An existing entry keeps its expiry, tags, metadata and version; a new one
+// gets the cache's default TTL, as with Set. Update reports whether a value is
+// stored under key when it returns, which is false if fn asked for a delete or
+// the new value was rejected by the cache's cost or size limit, in which case
+// the current value is left in place.
+//
+// fn runs with the key's shard locked for writing, so it must be quick and
+// must not call any method on the cache. If fn panics, the lock is released
+// and the entry left unchanged before the panic propagates.
+func (c *Cache[K, V]) Update(key K, fn func(old V, found bool) (V, bool)) bool {
+	now := c.clock.Now()
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	item, exists := s.data[key]
+	found := exists && item.live(now)
+	var old V
+	if found {
+		old = c.copyValue(item.value)
+	} else {
+		item = cacheItem[V]{ttl: c.defaultTTL}
+		if c.defaultTTL > 0 {
+			item.expiryTime = c.expiryAt(now, c.defaultTTL)
+		}
+	}
+
+	value, keep := callUnlockingOnPanic(s, func() (V, bool) { return fn(old, found) })
+	if !keep {
+		removed, _ := c.deleteLocked(s, key, now, nil)
+		s.mutex.Unlock()
+		c.notifyEvicted(removed)
+		return false
+	}
+	tags, meta, version := item.tags, item.meta, item.version
+	item, err := c.newItem(key, value, item.ttl, item.expiryTime)
+	item.tags, item.meta, item.version = tags, meta, version
+	if err != nil {
+		s.mutex.Unlock()
+		return false
+	}
+	evicted := c.storeLocked(s, key, item)
+	s.mutex.Unlock()
+
+	c.notifyEvicted(evicted)
+	return true
+}
@@ -0,0 +1,381 @@
+package keyvalstore
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSimpleCache_GetOrSet(t *testing.T) {
+	sut := NewSimpleCache[string](1 * time.Minute)
+
+	val, found := sut.GetOrSet("key1", "value1", time.Minute)
+	if found || val != "value1" {
+		t.Errorf("Expected GetOrSet to store 'value1', got '%s', found: %v", val, found)
+	}
+
+	val, found = sut.GetOrSet("key1", "value2", time.Minute)
+	if !found || val != "value1" {
+		t.Errorf("Expected GetOrSet to return the existing 'value1', got '%s', found: %v", val, found)
+	}
+
+	sut.SetWithTTL("expired", "old", -time.Second)
+	val, found = sut.GetOrSet("expired", "new", time.Minute)
+	if found || val != "new" {
+		t.Errorf("Expected GetOrSet to overwrite an expired entry, got '%s', found: %v", val, found)
+	}
+	if val, _ = sut.Get("expired"); val != "new" {
+		t.Errorf("Expected 'new' to be stored, got '%s'", val)
+	}
+}
+
+func TestSimpleCache_GetOrSetConcurrent(t *testing.T) {
+	sut := NewSimpleCache[int](1 * time.Minute)
+	const numGoroutines = 50
+
+	var wg sync.WaitGroup
+	results := make([]int, numGoroutines)
+	wg.Add(numGoroutines)
+	for i := 0; i < numGoroutines; i++ {
+		go func(id int) {
+			defer wg.Done()
+			results[id], _ = sut.GetOrSet("key", id, time.Minute)
+		}(i)
+	}
+	wg.Wait()
+
+	stored, _ := sut.Get("key")
+	for id, v := range results {
+		if v != stored {
+			t.Errorf("Expected goroutine %d to see the stored value %d, got %d", id, stored, v)
+		}
+	}
+}
+
+func TestSimpleCache_SetNX(t *testing.T) {
+	sut := NewSimpleCache[string](1 * time.Minute)
+
+	if !sut.SetNX("lock", "owner1", time.Minute) {
+		t.Errorf("Expected the first SetNX to succeed")
+	}
+	if sut.SetNX("lock", "owner2", time.Minute) {
+		t.Errorf("Expected SetNX to fail while a live value exists")
+	}
+	if val, _ := sut.Get("lock"); val != "owner1" {
+		t.Errorf("Expected 'owner1' to keep the lock, got '%s'", val)
+	}
+
+	sut.SetWithTTL("expired", "old", -time.Second)
+	if !sut.SetNX("expired", "new", time.Minute) {
+		t.Errorf("Expected SetNX to succeed over an expired entry")
+	}
+}
+
+func TestSimpleCache_Replace(t *testing.T) {
+	sut := NewSimpleCache[string](1 * time.Minute)
+
+	if sut.Replace("key1", "value1", time.Minute) {
+		t.Errorf("Expected Replace to fail for a missing key")
+	}
+	if sut.Has("key1") {
+		t.Errorf("Expected Replace not to create a missing key")
+	}
+
+	sut.SetWithTTL("key1", "value1", 10*time.Millisecond)
+	if !sut.Replace("key1", "value2", time.Minute) {
+		t.Errorf("Expected Replace to succeed for a live key")
+	}
+	time.Sleep(15 * time.Millisecond)
+	val, found := sut.Get("key1")
+	if !found || val != "value2" {
+		t.Errorf("Expected Replace to store 'value2' with a fresh TTL, got '%s', found: %v", val, found)
+	}
+
+	sut.SetWithTTL("expired", "old", -time.Second)
+	if sut.Replace("expired", "new", time.Minute) {
+		t.Errorf("Expected Replace to fail for an expired key")
+	}
+}
+
+func TestSimpleCache_GetAndDelete(t *testing.T) {
+	sut := NewSimpleCache[string](1 * time.Minute)
+
+	var reasons []EvictionReason
+	sut.OnEvicted(func(key string, value string, reason EvictionReason) {
+		reasons = append(reasons, reason)
+	})
+
+	sut.Set("token", "secret")
+	val, found := sut.GetAndDelete("token")
+	if !found || val != "secret" {
+		t.Errorf("Expected to pop 'secret', got '%s', found: %v", val, found)
+	}
+	if val, found = sut.GetAndDelete("token"); found {
+		t.Errorf("Expected a second GetAndDelete to find nothing, got '%s'", val)
+	}
+	if sut.Has("token") {
+		t.Errorf("Expected token to be removed")
+	}
+	if len(reasons) != 1 || reasons[0] != ReasonDeleted {
+		t.Errorf("Expected a single ReasonDeleted callback, got %v", reasons)
+	}
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	sut := NewSimpleCache[int](1 * time.Minute)
+
+	if CompareAndSwap(sut, "version", 0, 1, time.Minute) {
+		t.Errorf("Expected CompareAndSwap to fail for a missing key")
+	}
+
+	sut.Set("version", 1)
+	if CompareAndSwap(sut, "version", 2, 3, time.Minute) {
+		t.Errorf("Expected CompareAndSwap to fail when the old value differs")
+	}
+	if !CompareAndSwap(sut, "version", 1, 2, time.Minute) {
+		t.Errorf("Expected CompareAndSwap to succeed when the old value matches")
+	}
+	if val, _ := sut.Get("version"); val != 2 {
+		t.Errorf("Expected version to be 2, got %d", val)
+	}
+
+	sut.SetWithTTL("expired", 1, -time.Second)
+	if CompareAndSwap(sut, "expired", 1, 2, time.Minute) {
+		t.Errorf("Expected CompareAndSwap to fail for an expired key")
+	}
+}
+
+func TestIncrement(t *testing.T) {
+	sut := NewSimpleCache[int](1 * time.Minute)
+
+	val, err := Increment(sut, "views", 5, time.Minute)
+	if err != nil || val != 5 {
+		t.Errorf("Expected a missing key to start at 5, got %d, err: %v", val, err)
+	}
+	val, err = Increment(sut, "views", -2, time.Minute)
+	if err != nil || val != 3 {
+		t.Errorf("Expected the counter to be 3, got %d, err: %v", val, err)
+	}
+
+	sut.SetWithTTL("expired", 100, -time.Second)
+	if val, _ = Increment(sut, "expired", 1, time.Minute); val != 1 {
+		t.Errorf("Expected an expired counter to restart at 1, got %d", val)
+	}
+}
+
+func TestIncrementConcurrent(t *testing.T) {
+	sut := NewSimpleCache[int64](1 * time.Minute)
+	const numGoroutines = 50
+	const numIterations = 100
+
+	var wg sync.WaitGroup
+	wg.Add(numGoroutines)
+	for i := 0; i < numGoroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < numIterations; j++ {
+				Increment(sut, "counter", 1, time.Minute)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if val, _ := sut.Get("counter"); val != numGoroutines*numIterations {
+		t.Errorf("Expected counter to be %d, got %d", numGoroutines*numIterations, val)
+	}
+}
+
+func TestSimpleCache_Swap(t *testing.T) {
+	sut := NewSimpleCache[string](1 * time.Minute)
+	defer sut.Close()
+
+	val, found := sut.Swap("key1", "value1", time.Minute)
+	if found || val != "" {
+		t.Errorf("Expected no previous value, got '%s', found: %v", val, found)
+	}
+	val, found = sut.Swap("key1", "value2", time.Minute)
+	if !found || val != "value1" {
+		t.Errorf("Expected the previous value 'value1', got '%s', found: %v", val, found)
+	}
+	if val, _ = sut.Get("key1"); val != "value2" {
+		t.Errorf("Expected 'value2' to be stored, got '%s'", val)
+	}
+
+	sut.SetWithTTL("expired", "old", -time.Second)
+	val, found = sut.Swap("expired", "new", time.Minute)
+	if found || val != "" {
+		t.Errorf("Expected an expired value to be reported as absent, got '%s', found: %v", val, found)
+	}
+	if val, _ = sut.Get("expired"); val != "new" {
+		t.Errorf("Expected 'new' to be stored, got '%s'", val)
+	}
+}
+
+func TestSimpleCache_SwapRejectedByCost(t *testing.T) {
+	sut := NewSimpleCache(1*time.Minute, WithMaxCost(5, func(v string) int64 { return int64(len(v)) }))
+	defer sut.Close()
+
+	sut.SetWithTTL("key1", "small", time.Minute)
+	val, found := sut.Swap("key1", "much too large", time.Minute)
+	if !found || val != "small" {
+		t.Errorf("Expected the current value 'small', got '%s', found: %v", val, found)
+	}
+	if val, _ = sut.Get("key1"); val != "small" {
+		t.Errorf("Expected the oversized value not to be stored, got '%s'", val)
+	}
+}
+
+func TestSimpleCache_Update(t *testing.T) {
+	type counter struct{ hits int }
+	sut := NewSimpleCache[counter](1 * time.Minute)
+	defer sut.Close()
+
+	increment := func(old counter, found bool) (counter, bool) {
+		old.hits++
+		return old, true
+	}
+	if !sut.Update("key1", increment) {
+		t.Errorf("Expected Update to store a value for a missing key")
+	}
+	sut.Update("key1", increment)
+	if val, _ := sut.Get("key1"); val.hits != 2 {
+		t.Errorf("Expected 2 hits, got %d", val.hits)
+	}
+	if ttl, _ := sut.TTL("key1"); ttl != NoExpiration {
+		t.Errorf("Expected a new key to get the default TTL, got %v", ttl)
+	}
+
+	var deleted []string
+	sut.OnEvicted(func(key string, _ counter, reason EvictionReason) {
+		deleted = append(deleted, key+":"+reason.String())
+	})
+	if sut.Update("key1", func(counter, bool) (counter, bool) { return counter{}, false }) {
+		t.Errorf("Expected Update to report a deleted key as not stored")
+	}
+	if sut.Has("key1") || len(deleted) != 1 || deleted[0] != "key1:deleted" {
+		t.Errorf("Expected key1 to be deleted with ReasonDeleted, got %v", deleted)
+	}
+}
+
+func TestSimpleCache_UpdateKeepsExpiry(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithClock[int](clock))
+
+	sut.SetWithTTL("key1", 1, time.Hour)
+	clock.Advance(30 * time.Minute)
+	sut.Update("key1", func(old int, found bool) (int, bool) { return old + 1, true })
+	if ttl, _ := sut.TTL("key1"); ttl != 30*time.Minute {
+		t.Errorf("Expected Update to keep the remaining TTL of 30m, got %v", ttl)
+	}
+
+	sut.SetWithTTL("expired", 5, -time.Second)
+	sut.Update("expired", func(old int, found bool) (int, bool) {
+		if found || old != 0 {
+			t.Errorf("Expected an expired value to be reported as missing, got %d, found: %v", old, found)
+		}
+		return 1, true
+	})
+	if val, found := sut.Get("expired"); !found || val != 1 {
+		t.Errorf("Expected the expired key to be replaced, got %d, found: %v", val, found)
+	}
+}
+
+func TestSimpleCache_UpdateConcurrent(t *testing.T) {
+	sut := NewSimpleCache[int](1 * time.Minute)
+	defer sut.Close()
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sut.Update("key1", func(old int, _ bool) (int, bool) { return old + 1, true })
+		}()
+	}
+	wg.Wait()
+	if val, _ := sut.Get("key1"); val != 50 {
+		t.Errorf("Expected all 50 updates to apply, got %d", val)
+	}
+}
+
+func TestSimpleCache_UpdatePanicReleasesLock(t *testing.T) {
+	sut := NewSimpleCache[int](0)
+	sut.Set("key1", 1)
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("Expected the panic to propagate, got %v", r)
+			}
+		}()
+		sut.Update("key1", func(int, bool) (int, bool) { panic("boom") })
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if val, found := sut.Get("key1"); !found || val != 1 {
+			t.Errorf("Expected the panicking update to leave key1 unchanged, got %d, found: %v", val, found)
+		}
+		sut.Set("key1", 2)
+		if val, _ := sut.Get("key1"); val != 2 {
+			t.Errorf("Expected Set after the panic to apply, got %d", val)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the shard to be unlocked after fn panicked")
+	}
+}
+
+func TestSimpleCache_SetIfNewer(t *testing.T) {
+	sut := NewSimpleCache[string](1 * time.Minute)
+	defer sut.Close()
+
+	if !sut.SetIfNewer("key1", "v2", 2, time.Minute) {
+		t.Errorf("Expected SetIfNewer to store a missing key")
+	}
+	if sut.SetIfNewer("key1", "v1", 1, time.Minute) {
+		t.Errorf("Expected SetIfNewer to reject an older version")
+	}
+	if sut.SetIfNewer("key1", "v2 again", 2, time.Minute) {
+		t.Errorf("Expected SetIfNewer to reject the same version")
+	}
+	if val, _ := sut.Get("key1"); val != "v2" {
+		t.Errorf("Expected 'v2' to survive stale updates, got '%s'", val)
+	}
+	if !sut.SetIfNewer("key1", "v3", 3, time.Minute) {
+		t.Errorf("Expected SetIfNewer to store a newer version")
+	}
+	if val, _ := sut.Get("key1"); val != "v3" {
+		t.Errorf("Expected 'v3', got '%s'", val)
+	}
+
+	sut.SetWithTTL("key2", "expired", -time.Second)
+	if !sut.SetIfNewer("key2", "v1", 1, time.Minute) {
+		t.Errorf("Expected SetIfNewer to replace an expired entry")
+	}
+	sut.Set("key3", "plain")
+	if !sut.SetIfNewer("key3", "v1", 1, time.Minute) {
+		t.Errorf("Expected a value stored by Set to count as version zero")
+	}
+}
+
+func TestSimpleCache_SetIfNewerConcurrent(t *testing.T) {
+	sut := NewSimpleCache[int64](1 * time.Minute)
+	defer sut.Close()
+
+	var wg sync.WaitGroup
+	for i := range int64(50) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sut.SetIfNewer("key1", i, i, time.Minute)
+		}()
+	}
+	wg.Wait()
+	if val, _ := sut.Get("key1"); val != 49 {
+		t.Errorf("Expected the newest version to win, got %d", val)
+	}
+}
@@ -0,0 +1,212 @@
+package keyvalstore
+
+import "time"
+
+// GetMany returns the live values for keys, locking each shard involved once
+// and evaluating expiry against a single point in time. Missing and expired
+// keys are omitted from the result. Hits and misses are counted, and a hit
+// slides or extends the entry's expiry and is recorded with the eviction
+// policy as for Get. Unlike Get, GetMany leaves expired entries for the
+// janitor, does not call the WithOnMiss hook, and does not load missing keys
+// with WithLoader.
+func (c *Cache[K, V]) GetMany(keys []K) map[K]V {
+	result := make(map[K]V, len(keys))
+	recordAccess := c.readsMutate()
+	now := c.clock.Now()
+	for i, group := range c.groupByShard(keys) {
+		if len(group) == 0 {
+			continue
+		}
+		s := c.shards[i]
+		if recordAccess {
+			s.mutex.Lock()
+		} else {
+			s.mutex.RLock()
+		}
+		for _, key := range group {
+			item, exists := s.data[key]
+			if !exists || !item.live(now) {
+				c.stats.misses.Add(1)
+				continue
+			}
+			c.stats.hits.Add(1)
+			if recordAccess {
+				c.recordAccessLocked(s, key, item, now)
+			}
+			result[key] = c.copyValue(item.value)
+		}
+		if recordAccess {
+			s.mutex.Unlock()
+		} else {
+			s.mutex.RUnlock()
+		}
+	}
+	return result
+}
+
+// Entry is a live value together with its expiry, as returned by
+// GetManyWithExpiry.
+type Entry[V any] struct {
+	Value V
+	// ExpiryTime is when the value expires, or the zero time.Time if it
+	// never does.
+	ExpiryTime time.Time
+	// TTL is the remaining lifetime when the entry was read, or NoExpiration
+	// if it never expires.
+	TTL time.Duration
+}
+
+// GetManyWithExpiry is like GetMany, but returns each live value together with
+// its expiry time and remaining TTL, all evaluated against a single point in
+// time. Like GetWithExpiry, it counts hits and misses but does not slide expiry
+// or update LRU recency or LFU frequency.
+func (c *Cache[K, V]) GetManyWithExpiry(keys []K) map[K]Entry[V] {
+	result := make(map[K]Entry[V], len(keys))
+	now := c.clock.Now()
+	for i, group := range c.groupByShard(keys) {
+		if len(group) == 0 {
+			continue
+		}
+		s := c.shards[i]
+		s.mutex.RLock()
+		for _, key := range group {
+			item, exists := s.data[key]
+			if !exists || !item.live(now) {
+				c.stats.misses.Add(1)
+				continue
+			}
+			c.stats.hits.Add(1)
+			entry := Entry[V]{Value: c.copyValue(item.value), ExpiryTime: item.expiryTime, TTL: NoExpiration}
+			if !item.expiryTime.IsZero() {
+				entry.TTL = item.expiryTime.Sub(now)
+			}
+			result[key] = entry
+		}
+		s.mutex.RUnlock()
+	}
+	return result
+}
+
+// setManyBatchSize bounds how many entries SetMany stores per lock acquisition,
+// so bulk loads cannot starve other callers.
+const setManyBatchSize = 1024
+
+// SetMany stores all items with the same ttl, measured from the start of the call.
+// Entries are written in batches of up to setManyBatchSize per write-lock
+// acquisition, so a concurrent reader may observe a partially applied call.
+// Capacity limits are enforced as each entry is stored, and entries whose
+// cost exceeds the cache's maximum are skipped.
+func (c *Cache[K, V]) SetMany(items map[K]V, ttl time.Duration) {
+	now := c.clock.Now()
+	pending := make(map[K]cacheItem[V], min(len(items), setManyBatchSize))
+	for key, value := range items {
+		item, err := c.newItem(key, value, ttl, c.expiryAt(now, ttl))
+		if err != nil {
+			continue
+		}
+		pending[key] = item
+		if len(pending) == setManyBatchSize {
+			c.storeBatch(pending)
+			clear(pending)
+		}
+	}
+	c.storeBatch(pending)
+}
+
+// ReplaceAll replaces the entire contents of the cache with items, all stored
+// with the same ttl, e.g. to apply a reloaded configuration. Every shard is
+// write-locked for the duration, so readers see either the old contents or the
+// new ones, never a mix of both or an empty cache in between. Old entries are
+// reported to the eviction callback with ReasonReplaced if their key is among
+// the new items, as with Set, and with ReasonDeleted otherwise; those that had
+// already expired are reported with ReasonExpired. Capacity limits apply to the
+// new contents as they are stored, and items whose cost exceeds the cache's
+// maximum are skipped, leaving their key absent. It is a no-op on a closed
+// cache.
+func (c *Cache[K, V]) ReplaceAll(items map[K]V, ttl time.Duration) {
+	if c.closed.Load() {
+		return
+	}
+	now := c.clock.Now()
+	pending := make(map[K]cacheItem[V], len(items))
+	for key, value := range items {
+		if item, err := c.newItem(key, value, ttl, c.expiryAt(now, ttl)); err == nil {
+			pending[key] = item
+		}
+	}
+
+	collect := c.observesRemovals()
+	var removed []evictedEntry[K, V]
+	c.lockAll()
+	for _, s := range c.shards {
+		for key, old := range s.data {
+			if old.negative {
+				continue
+			}
+			reason := ReasonDeleted
+			if old.expired(now) {
+				reason = ReasonExpired
+				c.stats.expirations.Add(1)
+			} else if _, ok := pending[key]; ok {
+				reason = ReasonReplaced
+			}
+			if collect {
+				removed = c.removedLocked(removed, key, old.value, reason)
+			}
+		}
+		s.reset()
+	}
+	for key, item := range pending {
+		removed = append(removed, c.storeLocked(c.shardFor(key), key, item)...)
+	}
+	c.unlockAll()
+
+	c.notifyEvicted(removed)
+}
+
+// storeBatch stores items, locking each shard involved once.
+func (c *Cache[K, V]) storeBatch(items map[K]cacheItem[V]) {
+	if len(items) == 0 {
+		return
+	}
+	keys := make([]K, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+
+	var evicted []evictedEntry[K, V]
+	for i, group := range c.groupByShard(keys) {
+		if len(group) == 0 {
+			continue
+		}
+		s := c.shards[i]
+		s.mutex.Lock()
+		for _, key := range group {
+			evicted = append(evicted, c.storeLocked(s, key, items[key])...)
+		}
+		s.mutex.Unlock()
+	}
+
+	c.notifyEvicted(evicted)
+}
+
+// DeleteMany removes keys, locking each shard involved once and skipping keys
+// that are not present. The eviction callback, if any, receives each removed
+// key as Delete reports it.
+func (c *Cache[K, V]) DeleteMany(keys []K) {
+	now := c.clock.Now()
+	var deleted []evictedEntry[K, V]
+	for i, group := range c.groupByShard(keys) {
+		if len(group) == 0 {
+			continue
+		}
+		s := c.shards[i]
+		s.mutex.Lock()
+		for _, key := range group {
+			deleted, _ = c.deleteLocked(s, key, now, deleted)
+		}
+		s.mutex.Unlock()
+	}
+
+	c.notifyEvicted(deleted)
+}
@@ -0,0 +1,150 @@
+package keyvalstore
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestSimpleCache_GetMany(t *testing.T) {
+	sut := NewSimpleCache[string](1 * time.Minute)
+
+	sut.Set("key1", "value1")
+	sut.Set("key2", "value2")
+	sut.SetWithTTL("expired", "value3", -time.Second)
+
+	got := sut.GetMany([]string{"key1", "key2", "expired", "missing"})
+	if len(got) != 2 || got["key1"] != "value1" || got["key2"] != "value2" {
+		t.Errorf("Expected only key1 and key2 in the result, got %v", got)
+	}
+}
+
+func TestSimpleCache_GetManyWithExpiry(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithClock[string](clock), WithShards[string](4))
+
+	sut.SetWithTTL("key1", "value1", time.Hour)
+	sut.SetForever("key2", "value2")
+	sut.SetWithTTL("expired", "value3", -time.Second)
+	clock.Advance(10 * time.Minute)
+
+	got := sut.GetManyWithExpiry([]string{"key1", "key2", "expired", "missing"})
+	if len(got) != 2 {
+		t.Fatalf("Expected only the 2 live keys, got %v", got)
+	}
+	if e := got["key1"]; e.Value != "value1" || e.TTL != 50*time.Minute || !e.ExpiryTime.Equal(clock.Now().Add(50*time.Minute)) {
+		t.Errorf("Expected key1 to expire in 50m, got %+v", e)
+	}
+	if e := got["key2"]; e.Value != "value2" || e.TTL != NoExpiration || !e.ExpiryTime.IsZero() {
+		t.Errorf("Expected key2 to never expire, got %+v", e)
+	}
+}
+
+func TestSimpleCache_SetMany(t *testing.T) {
+	sut := NewSimpleCache[int](1 * time.Minute)
+
+	items := make(map[string]int, 3*setManyBatchSize)
+	for i := 0; i < 3*setManyBatchSize; i++ {
+		items[fmt.Sprintf("key%d", i)] = i
+	}
+	sut.SetMany(items, time.Minute)
+
+	if n := sut.Len(); n != len(items) {
+		t.Errorf("Expected %d entries, got %d", len(items), n)
+	}
+	if val, found := sut.Get("key42"); !found || val != 42 {
+		t.Errorf("Expected key42 to be 42, got %d, found: %v", val, found)
+	}
+}
+
+func TestSimpleCache_SetManyRespectsCapacity(t *testing.T) {
+	sut := NewSimpleCache(1*time.Minute, WithMaxEntries[int](2))
+
+	sut.SetMany(map[string]int{"a": 1, "b": 2, "c": 3}, time.Minute)
+	if n := sut.Len(); n != 2 {
+		t.Errorf("Expected SetMany to respect the entry limit of 2, got %d entries", n)
+	}
+}
+
+func TestSimpleCache_DeleteMany(t *testing.T) {
+	sut := NewSimpleCache[string](1 * time.Minute)
+
+	var deleted []string
+	sut.OnEvicted(func(key string, value string, reason EvictionReason) {
+		deleted = append(deleted, key)
+	})
+
+	sut.Set("key1", "value1")
+	sut.Set("key2", "value2")
+	sut.Set("key3", "value3")
+	sut.DeleteMany([]string{"key1", "key3", "missing"})
+
+	if keys := sut.Keys(); len(keys) != 1 || keys[0] != "key2" {
+		t.Errorf("Expected only key2 to remain, got %v", keys)
+	}
+	if len(deleted) != 2 {
+		t.Errorf("Expected two delete callbacks, got %v", deleted)
+	}
+}
+
+func TestSimpleCache_ReplaceAll(t *testing.T) {
+	sut := NewSimpleCache(0, WithShards[string](4))
+	var evicted []string
+	sut.OnEvicted(func(key string, _ string, reason EvictionReason) {
+		evicted = append(evicted, key+":"+reason.String())
+	})
+	sut.Set("kept", "old")
+	sut.Set("dropped", "old")
+	sut.SetWithTTL("expired", "old", -time.Second)
+
+	sut.ReplaceAll(map[string]string{"kept": "new", "added": "new"}, time.Minute)
+
+	items := sut.Items()
+	if len(items) != 2 || items["kept"] != "new" || items["added"] != "new" {
+		t.Errorf("Expected only the new items, got %v", items)
+	}
+	if ttl, ok := sut.TTL("added"); !ok || ttl <= 0 || ttl > time.Minute {
+		t.Errorf("Expected the new items to be stored with the ttl, got %v", ttl)
+	}
+	sort.Strings(evicted)
+	expected := []string{"dropped:deleted", "expired:expired", "kept:replaced"}
+	if !slices.Equal(evicted, expected) {
+		t.Errorf("Expected evictions %v, got %v", expected, evicted)
+	}
+}
+
+func TestSimpleCache_ReplaceAllIsAtomic(t *testing.T) {
+	sut := NewSimpleCache(0, WithShards[int](4))
+	old := map[string]int{"a": 1, "b": 1, "c": 1, "d": 1}
+	replacement := map[string]int{"a": 2, "b": 2, "c": 2, "d": 2}
+	sut.SetMany(old, time.Minute)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 200 {
+			if i%2 == 0 {
+				sut.ReplaceAll(replacement, time.Minute)
+			} else {
+				sut.ReplaceAll(old, time.Minute)
+			}
+		}
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		items := sut.Items()
+		seen := map[int]bool{}
+		for _, v := range items {
+			seen[v] = true
+		}
+		if len(items) != 4 || len(seen) != 1 {
+			t.Fatalf("Expected a reader to see one complete generation, got %v", items)
+		}
+	}
+}
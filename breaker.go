@@ -0,0 +1,149 @@
+package keyvalstore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a cache's loader circuit breaker, as reported in
+// Stats. See WithCircuitBreaker.
+type CircuitState int
+
+const (
+	// CircuitClosed means loads run normally. It is also reported for caches
+	// without a circuit breaker.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen means loads fail fast with ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen means the cooldown has elapsed and a single probe load
+	// decides whether the circuit closes again.
+	CircuitHalfOpen
+)
+
+// String returns the state's name, e.g. "open".
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// circuitBreaker counts consecutive loader failures and stops calling the
+// loader for a cooldown once there are too many. It is safe for concurrent use.
+type circuitBreaker struct {
+	threshold int
+	window    time.Duration
+	cooldown  time.Duration
+
+	mutex sync.Mutex
+	state CircuitState
+	// failures counts consecutive failures since firstFailure.
+	failures     int
+	firstFailure time.Time
+	openedAt     time.Time
+	// probing is set while the half-open probe load runs.
+	probing bool
+	opens   uint64
+}
+
+// allow reports whether a load may run at now, returning ErrCircuitOpen if it
+// may not. probe is true if the load is the half-open probe, in which case the
+// caller must pass its outcome to record, or call abandon if it never runs.
+func (b *circuitBreaker) allow(now time.Time) (probe bool, err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	switch b.state {
+	case CircuitOpen:
+		if now.Sub(b.openedAt) < b.cooldown {
+			return false, ErrCircuitOpen
+		}
+		b.state = CircuitHalfOpen
+		b.probing = true
+		return true, nil
+	case CircuitHalfOpen:
+		if b.probing {
+			return false, ErrCircuitOpen
+		}
+		b.probing = true
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// record updates the breaker with the outcome of a load that finished at now.
+// ErrNotFound is a successful answer from the backing store, and cancellation
+// says nothing about it, so neither counts as a failure.
+func (b *circuitBreaker) record(now time.Time, probe bool, err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if probe {
+		b.probing = false
+	}
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	if err == nil || errors.Is(err, ErrNotFound) {
+		if b.state == CircuitHalfOpen && probe {
+			b.state = CircuitClosed
+		}
+		b.failures = 0
+		return
+	}
+
+	if b.state == CircuitHalfOpen {
+		if probe {
+			b.open(now)
+		}
+		return
+	}
+	if b.failures == 0 || (b.window > 0 && now.Sub(b.firstFailure) > b.window) {
+		b.failures = 0
+		b.firstFailure = now
+	}
+	b.failures++
+	if b.state == CircuitClosed && b.failures >= b.threshold {
+		b.open(now)
+	}
+}
+
+// abandon releases the probe slot of a probe load that never ran.
+func (b *circuitBreaker) abandon(probe bool) {
+	if !probe {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.probing = false
+}
+
+// open opens the circuit at now. The caller must hold b's mutex.
+func (b *circuitBreaker) open(now time.Time) {
+	b.state = CircuitOpen
+	b.openedAt = now
+	b.failures = 0
+	b.opens++
+}
+
+// snapshot returns the breaker's state and how often it has opened, restarting
+// the count from zero if reset is true.
+func (b *circuitBreaker) snapshot(reset bool) (CircuitState, uint64) {
+	if b == nil {
+		return CircuitClosed, 0
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	opens := b.opens
+	if reset {
+		b.opens = 0
+	}
+	return b.state, opens
+}
@@ -0,0 +1,99 @@
+package keyvalstore
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSimpleCache_CircuitBreaker(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithClock[string](clock), WithCircuitBreaker[string](3, time.Minute, 30*time.Second))
+
+	failure := errors.New("backend down")
+	calls := 0
+	failing := func() (string, error) {
+		calls++
+		return "", failure
+	}
+	for i := range 3 {
+		if _, err := sut.GetOrLoad("key1", time.Minute, failing); !errors.Is(err, failure) {
+			t.Errorf("Expected load %d to return the loader's error, got %v", i, err)
+		}
+	}
+	if state := sut.Stats().CircuitState; state != CircuitOpen {
+		t.Fatalf("Expected the circuit to open after 3 failures, got %v", state)
+	}
+
+	if _, err := sut.GetOrLoad("key2", time.Minute, failing); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected an open circuit to fail fast, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected the loader not to be called while the circuit is open, got %d calls", calls)
+	}
+
+	// After the cooldown a failed probe opens the circuit again.
+	clock.Advance(31 * time.Second)
+	if _, err := sut.GetOrLoad("key1", time.Minute, failing); !errors.Is(err, failure) {
+		t.Errorf("Expected the probe to call the loader, got %v", err)
+	}
+	if stats := sut.Stats(); stats.CircuitState != CircuitOpen || stats.CircuitOpens != 2 {
+		t.Errorf("Expected a failed probe to reopen the circuit, got %v after %d opens", stats.CircuitState, stats.CircuitOpens)
+	}
+
+	// A successful probe closes it.
+	clock.Advance(31 * time.Second)
+	v, err := sut.GetOrLoad("key1", time.Minute, func() (string, error) { return "value1", nil })
+	if err != nil || v != "value1" {
+		t.Errorf("Expected the probe to load the value, got '%s', err: %v", v, err)
+	}
+	if state := sut.Stats().CircuitState; state != CircuitClosed {
+		t.Errorf("Expected a successful probe to close the circuit, got %v", state)
+	}
+}
+
+func TestSimpleCache_CircuitBreakerWindow(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithClock[string](clock), WithCircuitBreaker[string](2, time.Minute, time.Minute))
+
+	failing := func() (string, error) { return "", errors.New("backend down") }
+	sut.GetOrLoad("key1", time.Minute, failing)
+	clock.Advance(2 * time.Minute)
+	sut.GetOrLoad("key1", time.Minute, failing)
+	if state := sut.Stats().CircuitState; state != CircuitClosed {
+		t.Errorf("Expected failures further apart than the window not to open the circuit, got %v", state)
+	}
+
+	sut.GetOrLoad("key1", time.Minute, func() (string, error) { return "", ErrNotFound })
+	sut.GetOrLoad("key2", time.Minute, failing)
+	if state := sut.Stats().CircuitState; state != CircuitClosed {
+		t.Errorf("Expected ErrNotFound to reset the failure count, got %v", state)
+	}
+}
+
+func TestCircuitBreaker_SingleProbe(t *testing.T) {
+	now := time.Now()
+	sut := &circuitBreaker{threshold: 1, cooldown: time.Second}
+	sut.record(now, false, errors.New("failure"))
+
+	now = now.Add(2 * time.Second)
+	probe, err := sut.allow(now)
+	if !probe || err != nil {
+		t.Fatalf("Expected a probe once the cooldown elapsed, got %v, err: %v", probe, err)
+	}
+	if _, err = sut.allow(now); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected only one probe at a time, got %v", err)
+	}
+	sut.abandon(probe)
+	if probe, err = sut.allow(now); !probe || err != nil {
+		t.Errorf("Expected an abandoned probe to let another through, got %v, err: %v", probe, err)
+	}
+}
+
+func TestCircuitState_String(t *testing.T) {
+	for state, want := range map[CircuitState]string{CircuitClosed: "closed", CircuitOpen: "open", CircuitHalfOpen: "half-open"} {
+		if got := state.String(); got != want {
+			t.Errorf("Expected %s, got %s", want, got)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package keyvalstore
+
+// EvictionReason describes why an item left the cache.
+type EvictionReason int
+
+const (
+	// ReasonExpired means the item's TTL elapsed and it was reaped.
+	ReasonExpired EvictionReason = iota + 1
+	// ReasonCapacity means the item was evicted to make room under a
+	// capacity limit.
+	ReasonCapacity
+	// ReasonDeleted means the item was removed explicitly.
+	ReasonDeleted
+	// ReasonReplaced means the item was overwritten by a new value for the
+	// same key.
+	ReasonReplaced
+	// ReasonShutdown means the item was still in the cache when it was
+	// closed with CloseAndFlush.
+	ReasonShutdown
+)
+
+// String returns the reason's name, e.g. "expired".
+func (r EvictionReason) String() string {
+	switch r {
+	case ReasonExpired:
+		return "expired"
+	case ReasonCapacity:
+		return "capacity"
+	case ReasonDeleted:
+		return "deleted"
+	case ReasonReplaced:
+		return "replaced"
+	case ReasonShutdown:
+		return "shutdown"
+	default:
+		return "unknown"
+	}
+}
+
+// evictedEntry is an item removed from the cache, pending its eviction callback.
+type evictedEntry[K comparable, V any] struct {
+	key    K
+	value  V
+	reason EvictionReason
+}
+
+// OnEvicted registers fn to be called whenever an item leaves the cache, with
+// the reason it left: ReasonDeleted for Delete, ReasonExpired when the janitor
+// reaps it or Get or Delete finds it expired, ReasonCapacity when a capacity
+// policy evicts it, and ReasonReplaced when Set overwrites it (fn receives the
+// old value; an old value that had already expired is reported as
+// ReasonExpired).
+// CloseAndFlush reports the entries it removes with ReasonShutdown. Clear does
+// not trigger it. With WithBatchEvictionHandler, the items removed by the
+// janitor's sweeps are reported to that handler instead.
+// A nil fn removes the callback.
+//
+// fn runs after the cache's lock has been released, on the goroutine that
+// removed the item (the janitor's, for expirations), so it may call back into
+// the cache. Items removed by a single operation are reported in the order they
+// were removed; callbacks from concurrent operations may interleave.
+func (c *Cache[K, V]) OnEvicted(fn func(key K, value V, reason EvictionReason)) {
+	if fn == nil {
+		c.onEvicted.Store(nil)
+		return
+	}
+	c.onEvicted.Store(&fn)
+}
+
+// evictionCallback returns the registered eviction callback, or nil.
+func (c *Cache[K, V]) evictionCallback() func(key K, value V, reason EvictionReason) {
+	if fn := c.onEvicted.Load(); fn != nil {
+		return *fn
+	}
+	return nil
+}
+
+// removedLocked records that key left the cache for reason, appending the
+// entry to report to the eviction callback to removed. Its event is published
+// right away, while the caller still holds the write lock of the key's shard,
+// so that subscribers receive events in the order the changes were made.
+func (c *Cache[K, V]) removedLocked(removed []evictedEntry[K, V], key K, value V, reason EvictionReason) []evictedEntry[K, V] {
+	if typ, ok := reason.eventType(); ok {
+		c.publish(key, value, typ)
+	}
+	return append(removed, evictedEntry[K, V]{key: key, value: value, reason: reason})
+}
+
+// notifyEvicted reports entries, already published to subscribers by
+// removedLocked, to the eviction callback, if one is registered. It must be
+// called without holding any shard lock.
+func (c *Cache[K, V]) notifyEvicted(entries []evictedEntry[K, V]) {
+	if len(entries) == 0 {
+		return
+	}
+	fn := c.evictionCallback()
+	for _, e := range entries {
+		if fn != nil {
+			c.guard(func() { fn(e.key, e.value, e.reason) })
+		}
+	}
+}
+
+// notifyBatch reports entries removed for reason in one call to the
+// WithBatchEvictionHandler handler. It must be called without holding any
+// shard lock.
+func (c *Cache[K, V]) notifyBatch(entries []evictedEntry[K, V], reason EvictionReason) {
+	if len(entries) == 0 {
+		return
+	}
+	items := make(map[K]V, len(entries))
+	for _, e := range entries {
+		items[e.key] = e.value
+	}
+	c.guard(func() { c.onBatchEvicted(items, reason) })
+}
+
+// guard calls fn, passing a panic in it to the WithPanicHandler handler instead
+// of propagating it, if one is set.
+func (c *config[V]) guard(fn func()) {
+	if c.panicHandler != nil {
+		defer func() {
+			if r := recover(); r != nil {
+				c.panicHandler(r)
+			}
+		}()
+	}
+	fn()
+}
@@ -0,0 +1,246 @@
+package keyvalstore
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSimpleCache_OnEvictedDelete(t *testing.T) {
+	sut := NewSimpleCache[string](1 * time.Minute)
+	defer sut.Close()
+
+	var evicted []string
+	sut.OnEvicted(func(key string, value string, reason EvictionReason) {
+		evicted = append(evicted, key+"="+value+":"+reason.String())
+	})
+
+	sut.Set("key1", "value1")
+	sut.Delete("key1")
+	sut.Delete("key1")
+	sut.Set("key2", "value2")
+	sut.Clear()
+
+	if len(evicted) != 1 || evicted[0] != "key1=value1:deleted" {
+		t.Errorf("Expected a single delete callback for key1, got %v", evicted)
+	}
+}
+
+func TestSimpleCache_OnEvictedReplaced(t *testing.T) {
+	sut := NewSimpleCache[string](1 * time.Minute)
+	defer sut.Close()
+
+	var evicted []string
+	sut.OnEvicted(func(key string, value string, reason EvictionReason) {
+		evicted = append(evicted, key+"="+value+":"+reason.String())
+	})
+
+	sut.Set("key1", "value1")
+	sut.Set("key1", "value2")
+
+	if len(evicted) != 1 || evicted[0] != "key1=value1:replaced" {
+		t.Errorf("Expected the old value to be reported as replaced, got %v", evicted)
+	}
+}
+
+func TestSimpleCache_OnEvictedCapacity(t *testing.T) {
+	sut := NewSimpleCache(1*time.Minute, WithMaxEntries[string](1))
+	defer sut.Close()
+
+	var evicted []string
+	sut.OnEvicted(func(key string, value string, reason EvictionReason) {
+		evicted = append(evicted, key+":"+reason.String())
+	})
+
+	sut.Set("key1", "value1")
+	sut.Set("key2", "value2")
+	if len(evicted) != 1 || evicted[0] != "key1:capacity" {
+		t.Errorf("Expected key1 to be reported as evicted for capacity, got %v", evicted)
+	}
+}
+
+func TestSimpleCache_OnEvictedExpiration(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithClock[string](clock))
+
+	var mu sync.Mutex
+	var evicted []string
+	sut.OnEvicted(func(key string, value string, reason EvictionReason) {
+		// Re-entering the cache must not deadlock.
+		sut.Has(key)
+		mu.Lock()
+		evicted = append(evicted, key+":"+reason.String())
+		mu.Unlock()
+	})
+
+	sut.SetWithTTL("key1", "value1", time.Minute)
+	clock.Advance(2 * time.Minute)
+	sut.sweep(false)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(evicted) != 1 || evicted[0] != "key1:expired" {
+		t.Errorf("Expected key1 to be reported as expired, got %v", evicted)
+	}
+}
+
+func TestSimpleCache_SlowExpirationCallbackDoesNotBlock(t *testing.T) {
+	sut := NewSimpleCache[string](1 * time.Millisecond)
+	defer sut.Close()
+
+	type closed struct{ key, value string }
+	inCallback := make(chan closed, 1)
+	release := make(chan struct{})
+	sut.OnEvicted(func(key string, value string, reason EvictionReason) {
+		if reason == ReasonExpired {
+			inCallback <- closed{key, value}
+			<-release
+		}
+	})
+
+	sut.SetWithTTL("conn", "connection", time.Millisecond)
+	var got closed
+	select {
+	case got = <-inCallback:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the janitor to report the expired entry")
+	}
+	if got.key != "conn" || got.value != "connection" {
+		t.Errorf("Expected the janitor to hand over the expired value, got %+v", got)
+	}
+
+	// The janitor is stuck in the callback; other operations must not wait for it.
+	done := make(chan struct{})
+	go func() {
+		sut.Set("key1", "value1")
+		sut.Get("key1")
+		sut.Delete("key1")
+		sut.Len()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("Expected cache operations to proceed while the expiration callback runs")
+	}
+	close(release)
+}
+
+func TestSimpleCache_WithBatchEvictionHandler(t *testing.T) {
+	clock := newFakeClock()
+	var batches []map[string]string
+	sut := NewSimpleCache(0,
+		WithClock[string](clock),
+		WithShards[string](4),
+		WithBatchEvictionHandler(func(items map[string]string, reason EvictionReason) {
+			if reason != ReasonExpired {
+				t.Errorf("Expected ReasonExpired, got %v", reason)
+			}
+			batches = append(batches, items)
+		}),
+	)
+	var single []string
+	sut.OnEvicted(func(key string, _ string, reason EvictionReason) {
+		single = append(single, key+":"+reason.String())
+	})
+
+	for i := range 10 {
+		sut.SetWithTTL(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i), time.Minute)
+	}
+	sut.SetForever("kept", "value")
+	clock.Advance(2 * time.Minute)
+
+	if n := sut.DeleteExpired(); n != 10 {
+		t.Errorf("Expected 10 items to be removed, got %d", n)
+	}
+	if len(batches) != 1 || len(batches[0]) != 10 || batches[0]["key3"] != "value3" {
+		t.Errorf("Expected one batch of 10 items, got %v", batches)
+	}
+	if len(single) != 0 {
+		t.Errorf("Expected the batch handler to take precedence over OnEvicted, got %v", single)
+	}
+
+	sut.DeleteExpired()
+	if len(batches) != 1 {
+		t.Errorf("Expected no call for a sweep that removes nothing, got %d calls", len(batches))
+	}
+	sut.Delete("kept")
+	if len(single) != 1 || single[0] != "kept:deleted" {
+		t.Errorf("Expected OnEvicted to still receive other removals, got %v", single)
+	}
+}
+
+func TestSimpleCache_WithPanicHandler(t *testing.T) {
+	var mu sync.Mutex
+	var recovered []any
+	sut := NewSimpleCache(time.Millisecond, WithPanicHandler[string](func(r any) {
+		mu.Lock()
+		defer mu.Unlock()
+		recovered = append(recovered, r)
+	}))
+	defer sut.Close()
+	sut.OnEvicted(func(key string, value string, reason EvictionReason) {
+		panic(fmt.Sprintf("%s %s", key, reason))
+	})
+
+	sut.Set("key1", "value1")
+	sut.Delete("key1")
+	sut.SetWithTTL("key2", "value2", time.Millisecond)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		n := len(recovered)
+		mu.Unlock()
+		if n == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	mu.Lock()
+	got := fmt.Sprint(recovered)
+	mu.Unlock()
+	if got != "[key1 deleted key2 expired]" {
+		t.Errorf("Expected both callback panics to be recovered, got %v", got)
+	}
+
+	// The janitor must still be running after the panic on its goroutine.
+	sut.OnEvicted(nil)
+	sut.SetWithTTL("key3", "value3", time.Millisecond)
+	deadline = time.Now().Add(time.Second)
+	for sut.Stats().Expirations < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := sut.Stats().Expirations; n != 2 {
+		t.Errorf("Expected the janitor to keep reaping expired items, got %d expirations", n)
+	}
+	if !sut.Set("key4", "value4") || !sut.Has("key4") {
+		t.Errorf("Expected the cache to keep working after a callback panicked")
+	}
+}
+
+func TestSimpleCache_OnEvictedExpiredOnDelete(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithClock[string](clock))
+
+	var reasons []string
+	sut.OnEvicted(func(key string, value string, reason EvictionReason) {
+		reasons = append(reasons, key+" "+reason.String())
+	})
+	for _, key := range []string{"single", "many", "prefix"} {
+		sut.SetWithTTL(key, "value", time.Second)
+	}
+	clock.Advance(2 * time.Second)
+
+	sut.Delete("single")
+	sut.DeleteMany([]string{"many"})
+	DeletePrefix(sut, "pre")
+	if got := fmt.Sprint(reasons); got != "[single expired many expired prefix expired]" {
+		t.Errorf("Expected every delete of an expired entry to report ReasonExpired, got %v", got)
+	}
+	if n := sut.Stats().Expirations; n != 3 {
+		t.Errorf("Expected each expired entry to count as an expiration, got %d", n)
+	}
+}
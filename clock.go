@@ -0,0 +1,32 @@
+package keyvalstore
+
+import "time"
+
+// Clock is the source of the current time used for all expiry calculations.
+// It lets tests control expiration without sleeping.
+//
+// Expiry times are computed by adding a TTL to a reading of Now and compared
+// against later readings, so a Clock whose readings carry a monotonic clock
+// reading, as those of time.Now do, makes expiration immune to changes of the
+// wall clock such as NTP adjustments. See the time package's documentation of
+// monotonic clocks. A Clock without monotonic readings that goes backwards
+// extends the life of every entry by the size of the jump.
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock reads the system clock. Its readings include the monotonic clock,
+// which Go uses whenever two of them are compared or subtracted, so expiry
+// deadlines derived from them are unaffected by wall-clock jumps.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// clockFunc adapts a function returning the current time to a Clock.
+type clockFunc func() time.Time
+
+func (f clockFunc) Now() time.Time {
+	return f()
+}
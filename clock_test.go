@@ -0,0 +1,129 @@
+package keyvalstore
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock that only moves when advanced.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+func TestSimpleCache_WithClock(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithClock[string](clock))
+
+	sut.SetWithTTL("key1", "value1", time.Hour)
+	clock.Advance(59 * time.Minute)
+	if val, found := sut.Get("key1"); !found || val != "value1" {
+		t.Errorf("Expected key1 to be live before its TTL, got '%s', found: %v", val, found)
+	}
+	if ttl, _ := sut.TTL("key1"); ttl != time.Minute {
+		t.Errorf("Expected a remaining TTL of 1m, got %v", ttl)
+	}
+
+	clock.Advance(2 * time.Minute)
+	if val, found := sut.Get("key1"); found {
+		t.Errorf("Expected key1 to expire after advancing the clock, but got value '%s'", val)
+	}
+}
+
+func TestSimpleCache_WithClockJanitor(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithClock[string](clock))
+
+	sut.SetWithTTL("key1", "value1", time.Hour)
+	sut.sweep(false)
+	if !sut.Has("key1") {
+		t.Errorf("Expected the janitor to keep key1 while the clock is frozen")
+	}
+
+	clock.Advance(2 * time.Hour)
+	sut.sweep(false)
+	if isStored(sut, "key1") {
+		t.Errorf("Expected the janitor to reap key1 once the clock passed its expiry")
+	}
+}
+
+func TestSimpleCache_ClockJumpBackwards(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithClock[string](clock))
+
+	sut.SetWithTTL("key1", "value1", time.Minute)
+	clock.Advance(-time.Hour)
+	if val, found := sut.Get("key1"); !found || val != "value1" {
+		t.Errorf("Expected a backward clock jump not to expire key1, got '%s', found: %v", val, found)
+	}
+	if ttl, _ := sut.TTL("key1"); ttl != time.Hour+time.Minute {
+		t.Errorf("Expected the remaining TTL to report the stored expiry, 61m away, got %v", ttl)
+	}
+
+	clock.Advance(time.Hour + 2*time.Minute)
+	if _, found := sut.Get("key1"); found {
+		t.Errorf("Expected key1 to expire once the clock passed its expiry again")
+	}
+}
+
+func TestRealClock_MonotonicExpiry(t *testing.T) {
+	sut := NewSimpleCache[string](0)
+	sut.SetWithTTL("key1", "value1", time.Hour)
+
+	var saved bytes.Buffer
+	if err := sut.Save(&saved); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded := NewSimpleCache[string](0)
+	if err := loaded.Load(&saved); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	// Times with a monotonic clock reading print it as "m=".
+	for name, c := range map[string]*SimpleCache[string]{"stored": sut, "loaded": loaded} {
+		if _, expiry, _ := c.GetWithExpiry("key1"); !strings.Contains(expiry.String(), "m=") {
+			t.Errorf("Expected the %s expiry to carry a monotonic clock reading, got %v", name, expiry)
+		}
+	}
+}
+
+func TestSimpleCache_WithNowFunc(t *testing.T) {
+	frozen := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var calls atomic.Int32
+	sut := NewSimpleCache(0, WithNowFunc[string](func() time.Time {
+		calls.Add(1)
+		return frozen
+	}))
+
+	sut.SetWithTTL("key1", "value1", time.Millisecond)
+	sut.sweep(false)
+	if val, found := sut.Get("key1"); !found || val != "value1" {
+		t.Errorf("Expected nothing to expire while time is frozen, got '%s', found: %v", val, found)
+	}
+	if ttl, _ := sut.TTL("key1"); ttl != time.Millisecond {
+		t.Errorf("Expected the full TTL to remain while time is frozen, got %v", ttl)
+	}
+	if calls.Load() == 0 {
+		t.Errorf("Expected the cache to read the time from the given function")
+	}
+}
@@ -0,0 +1,47 @@
+package keyvalstore
+
+import (
+	"maps"
+	"slices"
+)
+
+// Clone returns a new cache holding a copy of every live entry, with the same
+// expiry times, tags and metadata, and the same configuration, including the current
+// cleanup interval. The clone has its own janitor, which must be stopped with
+// Close, and shares no internal state with c, so later changes to either cache
+// do not affect the other. Eviction callbacks, subscribers, statistics and the
+// eviction policy's recency or frequency history are not carried over.
+//
+// Values are copied shallowly, as assignment does, unless the cache was created
+// with WithCopier, in which case each value is copied with it. The entries are
+// read under the read lock, so the clone is a consistent snapshot of c.
+func (c *Cache[K, V]) Clone() *Cache[K, V] {
+	c.janitorMutex.Lock()
+	cfg := c.config
+	c.janitorMutex.Unlock()
+
+	items := c.snapshot()
+	clone := newCache[K](cfg, c.seed)
+	clone.storeBatch(items)
+	return clone
+}
+
+// snapshot copies the cache's live entries.
+func (c *Cache[K, V]) snapshot() map[K]cacheItem[V] {
+	c.rlockAll()
+	defer c.runlockAll()
+	now := c.clock.Now()
+	items := make(map[K]cacheItem[V], c.storedLocked())
+	for _, s := range c.shards {
+		for k, it := range s.data {
+			if !it.live(now) {
+				continue
+			}
+			it.value = c.copyValue(it.value)
+			it.tags = slices.Clone(it.tags)
+			it.meta = maps.Clone(it.meta)
+			items[k] = it
+		}
+	}
+	return items
+}
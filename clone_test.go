@@ -0,0 +1,77 @@
+package keyvalstore
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSimpleCache_Clone(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(1*time.Minute, WithClock[string](clock))
+	defer sut.Close()
+
+	sut.SetWithTTL("key1", "value1", time.Hour)
+	sut.SetForever("key2", "value2")
+	sut.SetWithTags("key3", "value3", time.Hour, "group")
+	sut.SetWithTTL("expired", "value4", -time.Second)
+
+	clone := sut.Clone()
+	defer clone.Close()
+
+	if n := clone.Len(); n != 3 {
+		t.Errorf("Expected the clone to hold the 3 live entries, got %d", n)
+	}
+	if ttl, _ := clone.TTL("key1"); ttl != time.Hour {
+		t.Errorf("Expected the clone to keep key1's expiry, got a TTL of %v", ttl)
+	}
+	if ttl, _ := clone.TTL("key2"); ttl != NoExpiration {
+		t.Errorf("Expected key2 to never expire in the clone, got %v", ttl)
+	}
+	if isStored(clone, "expired") {
+		t.Errorf("Expected expired entries not to be cloned")
+	}
+
+	sut.Set("key1", "changed")
+	sut.Delete("key2")
+	clone.Set("key4", "value4")
+	if val, _ := clone.Get("key1"); val != "value1" {
+		t.Errorf("Expected the clone to be unaffected by the original, got '%s'", val)
+	}
+	if !clone.Has("key2") || sut.Has("key4") {
+		t.Errorf("Expected the caches to change independently")
+	}
+	if n := clone.InvalidateTag("group"); n != 1 || !sut.Has("key3") {
+		t.Errorf("Expected the clone to keep its own tag index, invalidated %d", n)
+	}
+}
+
+func TestSimpleCache_CloneWithCopier(t *testing.T) {
+	sut := NewSimpleCache(0, WithCopier(slices.Clone[[]int]))
+	sut.Set("key1", []int{1, 2, 3})
+
+	clone := sut.Clone()
+	stored, _ := clone.Peek("key1")
+	original, _ := sut.Peek("key1")
+	if &stored[0] == &original[0] {
+		t.Errorf("Expected the clone to copy values with the configured copier")
+	}
+}
+
+func TestSimpleCache_CloneKeepsConfiguration(t *testing.T) {
+	sut := NewSimpleCache(0, WithMaxEntries[string](8), WithShards[string](4))
+	for i := range 8 {
+		sut.Set(strconv.Itoa(i), "value")
+	}
+
+	// The clone places keys in the same shards, so whatever survived the
+	// per-shard limits in the original fits in the clone too.
+	clone := sut.Clone()
+	if clone.Len() != sut.Len() {
+		t.Errorf("Expected all %d entries to fit in the clone, got %d", sut.Len(), clone.Len())
+	}
+	if len(clone.shards) != 4 || clone.maxEntries != 8 || clone.janitorRunning {
+		t.Errorf("Expected the clone to share the original's configuration")
+	}
+}
@@ -0,0 +1,73 @@
+package keyvalstore
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+// dumpEntry is an entry collected by Dump.
+type dumpEntry[K comparable, V any] struct {
+	key   string
+	value V
+	ttl   time.Duration
+}
+
+// Dump writes a human-readable listing of the live entries to w, one per line
+// and sorted by key: the key, its remaining TTL or "never", and the value,
+// separated by tabs. Values are written with format if it is non-nil, and
+// otherwise as by fmt.Print, which uses a String method if the value type has
+// one. A format function or String method that panics does not stop the dump;
+// the panic is written in place of the value. Dump returns the first error
+// from w.
+//
+// Dump is meant for debugging and ops tooling, not for persistence: the format
+// may change, and values are not escaped. The entries are copied under every
+// shard's read lock, which is released before anything is formatted or
+// written, so a slow w does not block the cache.
+func (c *Cache[K, V]) Dump(w io.Writer, format func(value V) string) error {
+	c.rlockAll()
+	now := c.clock.Now()
+	entries := make([]dumpEntry[K, V], 0, c.storedLocked())
+	for _, s := range c.shards {
+		for key, it := range s.data {
+			if !it.live(now) {
+				continue
+			}
+			ttl := NoExpiration
+			if !it.expiryTime.IsZero() {
+				ttl = it.expiryTime.Sub(now)
+			}
+			entries = append(entries, dumpEntry[K, V]{key: fmt.Sprint(key), value: c.copyValue(it.value), ttl: ttl})
+		}
+	}
+	c.runlockAll()
+
+	slices.SortFunc(entries, func(a, b dumpEntry[K, V]) int { return cmp.Compare(a.key, b.key) })
+	for _, e := range entries {
+		ttl := "never"
+		if e.ttl != NoExpiration {
+			ttl = e.ttl.Round(time.Millisecond).String()
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\n", e.key, ttl, formatValue(e.value, format)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatValue formats v for Dump, with format if it is non-nil, reporting a
+// panic in format instead of propagating it.
+func formatValue[V any](v V, format func(V) string) (s string) {
+	if format == nil {
+		return fmt.Sprint(v)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			s = fmt.Sprintf("%%!(PANIC=%v)", r)
+		}
+	}()
+	return format(v)
+}
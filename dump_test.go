@@ -0,0 +1,68 @@
+package keyvalstore
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type dumpStringer struct{ name string }
+
+func (s *dumpStringer) String() string { return "stringer:" + s.name }
+
+func TestSimpleCache_Dump(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithClock[*dumpStringer](clock), WithShards[*dumpStringer](4))
+	sut.SetWithTTL("b", &dumpStringer{"two"}, time.Minute)
+	sut.SetForever("a", &dumpStringer{"one"})
+	sut.SetWithTTL("expired", &dumpStringer{"gone"}, -time.Second)
+	sut.SetForever("nil", nil)
+
+	var out strings.Builder
+	if err := sut.Dump(&out, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := "a\tnever\tstringer:one\n" +
+		"b\t1m0s\tstringer:two\n" +
+		"nil\tnever\t<nil>\n"
+	if out.String() != expected {
+		t.Errorf("Expected dump\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+func TestSimpleCache_DumpFormatter(t *testing.T) {
+	sut := NewSimpleCache[int](0)
+	sut.SetForever("a", 1)
+	sut.SetForever("b", 2)
+
+	var out strings.Builder
+	err := sut.Dump(&out, func(v int) string {
+		if v == 2 {
+			panic("boom")
+		}
+		return "#" + strconv.Itoa(v)
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := "a\tnever\t#1\nb\tnever\t%!(PANIC=boom)\n"
+	if out.String() != expected {
+		t.Errorf("Expected dump\n%s\ngot\n%s", expected, out.String())
+	}
+}
+
+type failingWriter struct{ err error }
+
+func (w failingWriter) Write([]byte) (int, error) { return 0, w.err }
+
+func TestSimpleCache_DumpWriteError(t *testing.T) {
+	sut := NewSimpleCache[string](0)
+	sut.Set("a", "value")
+	errWrite := errors.New("disk full")
+
+	if err := sut.Dump(failingWriter{errWrite}, nil); !errors.Is(err, errWrite) {
+		t.Errorf("Expected the writer's error, got %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package keyvalstore
+
+import "errors"
+
+// ErrItemTooLarge is returned when a value's cost or estimated size exceeds the
+// cache's maximum cost or size, so it cannot be stored.
+var ErrItemTooLarge = errors.New("keyvalstore: item exceeds the maximum cost or size")
+
+// ErrLoaderFailed wraps the error returned by a GetOrLoad or WithLoader loader,
+// so callers can tell a failed load from other errors with errors.Is, while
+// errors.Is and errors.As still match the loader's own error. A loader that
+// panics also fails with ErrLoaderFailed. ErrNotFound is returned unwrapped.
+var ErrLoaderFailed = errors.New("keyvalstore: loader failed")
+
+// ErrCircuitOpen is returned by GetOrLoad instead of calling the loader while
+// the cache's circuit breaker is open. See WithCircuitBreaker.
+var ErrCircuitOpen = errors.New("keyvalstore: loader circuit breaker is open")
+
+// ErrNotFound is returned by a GetOrLoad loader to report that the key does not
+// exist in the backing store. With WithNegativeCaching the absence is cached,
+// and GetOrLoad returns ErrNotFound without calling the loader again until the
+// cached miss expires.
+var ErrNotFound = errors.New("keyvalstore: not found")
+
+// ErrClosed is returned by methods that would store a value, or load one, in a
+// cache that has been closed. See Close.
+var ErrClosed = errors.New("keyvalstore: cache is closed")
+
+// errValuePanicked is returned by Store when the cost function, size estimator
+// or copier panicked on the value and WithPanicHandler recovered the panic.
+var errValuePanicked = errors.New("keyvalstore: value estimator or copier panicked")
@@ -0,0 +1,131 @@
+package keyvalstore
+
+import "sync"
+
+// EventType identifies the kind of change a CacheEvent reports.
+type EventType int
+
+const (
+	// EventSet means a value was stored, whether new or replacing another.
+	EventSet EventType = iota + 1
+	// EventDelete means a value was removed explicitly.
+	EventDelete
+	// EventExpire means a value was removed because its TTL elapsed.
+	EventExpire
+	// EventEvict means a value was removed to make room under a capacity
+	// limit.
+	EventEvict
+)
+
+// String returns the event type's name, e.g. "set".
+func (e EventType) String() string {
+	switch e {
+	case EventSet:
+		return "set"
+	case EventDelete:
+		return "delete"
+	case EventExpire:
+		return "expire"
+	case EventEvict:
+		return "evict"
+	default:
+		return "unknown"
+	}
+}
+
+// Event describes a change to a single key. For EventSet, Value is the value
+// stored; otherwise it is the value that was removed.
+type Event[K comparable, V any] struct {
+	Key   K
+	Value V
+	Type  EventType
+}
+
+// CacheEvent is the Event type delivered by a SimpleCache.
+type CacheEvent[T any] = Event[string, T]
+
+// subscriberBuffer is the capacity of each channel returned by Subscribe.
+const subscriberBuffer = 256
+
+// subscribers is the set of channels receiving a cache's events.
+type subscribers[K comparable, V any] struct {
+	mutex    sync.RWMutex
+	channels map[<-chan Event[K, V]]chan Event[K, V]
+}
+
+// Subscribe returns a channel that receives an event for every value stored,
+// deleted, expired or evicted from now on. Clear produces no events, and
+// cached loader misses (see WithNegativeCaching) are not reported. Events for
+// a key are sent in the order its changes were made, even by concurrent
+// callers.
+//
+// Events are sent without blocking, so the cache never waits for a slow
+// subscriber: the channel buffers up to 256 events, and events that arrive
+// while it is full are dropped and counted in Stats().DroppedEvents. Call
+// Unsubscribe to stop delivery and close the channel.
+func (c *Cache[K, V]) Subscribe() <-chan Event[K, V] {
+	ch := make(chan Event[K, V], subscriberBuffer)
+	c.subscribers.mutex.Lock()
+	defer c.subscribers.mutex.Unlock()
+	if c.subscribers.channels == nil {
+		c.subscribers.channels = make(map[<-chan Event[K, V]]chan Event[K, V])
+	}
+	c.subscribers.channels[ch] = ch
+	c.subscriberCount.Add(1)
+	return ch
+}
+
+// Unsubscribe stops delivering events to ch, a channel returned by Subscribe,
+// and closes it once no more events can be sent. Events already buffered can
+// still be received. Unsubscribing a channel twice is a no-op.
+func (c *Cache[K, V]) Unsubscribe(ch <-chan Event[K, V]) {
+	c.subscribers.mutex.Lock()
+	defer c.subscribers.mutex.Unlock()
+	send, ok := c.subscribers.channels[ch]
+	if !ok {
+		return
+	}
+	delete(c.subscribers.channels, ch)
+	c.subscriberCount.Add(-1)
+	close(send)
+}
+
+// observesRemovals reports whether anything is listening for removed entries,
+// so callers can skip collecting them otherwise.
+func (c *Cache[K, V]) observesRemovals() bool {
+	return c.evictionCallback() != nil || c.onBatchEvicted != nil || c.subscriberCount.Load() > 0
+}
+
+// publish sends an event to every subscriber without blocking, dropping it for
+// subscribers whose buffer is full. It is safe to call with a shard lock held.
+func (c *Cache[K, V]) publish(key K, value V, typ EventType) {
+	if c.subscriberCount.Load() == 0 {
+		return
+	}
+	event := Event[K, V]{Key: key, Value: value, Type: typ}
+	c.subscribers.mutex.RLock()
+	defer c.subscribers.mutex.RUnlock()
+	for _, ch := range c.subscribers.channels {
+		select {
+		case ch <- event:
+		default:
+			c.stats.droppedEvents.Add(1)
+		}
+	}
+}
+
+// eventType returns the event reported for an entry removed for reason r.
+// Replacements are reported by the EventSet for the new value, so they have
+// no event of their own.
+func (r EvictionReason) eventType() (EventType, bool) {
+	switch r {
+	case ReasonExpired:
+		return EventExpire, true
+	case ReasonCapacity:
+		return EventEvict, true
+	case ReasonDeleted:
+		return EventDelete, true
+	default:
+		return 0, false
+	}
+}
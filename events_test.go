@@ -0,0 +1,148 @@
+package keyvalstore
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// receiveEvents drains the events currently buffered in ch.
+func receiveEvents[T any](ch <-chan CacheEvent[T]) []CacheEvent[T] {
+	var events []CacheEvent[T]
+	for {
+		select {
+		case e := <-ch:
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func TestSimpleCache_Subscribe(t *testing.T) {
+	sut := NewSimpleCache(0, WithMaxEntries[string](2))
+	defer sut.Close()
+	events := sut.Subscribe()
+
+	sut.Set("key1", "value1")
+	sut.Set("key1", "value2")
+	sut.Set("key2", "value3")
+	sut.Set("key3", "value4")
+	sut.Delete("key2")
+	sut.SetWithTTL("key4", "value5", -time.Second)
+	sut.Get("key4")
+
+	want := []CacheEvent[string]{
+		{Key: "key1", Value: "value1", Type: EventSet},
+		{Key: "key1", Value: "value2", Type: EventSet},
+		{Key: "key2", Value: "value3", Type: EventSet},
+		{Key: "key3", Value: "value4", Type: EventSet},
+		{Key: "key1", Value: "value2", Type: EventEvict},
+		{Key: "key2", Value: "value3", Type: EventDelete},
+		{Key: "key4", Value: "value5", Type: EventSet},
+		{Key: "key4", Value: "value5", Type: EventExpire},
+	}
+	got := receiveEvents(events)
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d events, got %d: %v", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected event %d to be %v, got %v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestSimpleCache_SubscribeJanitorExpiry(t *testing.T) {
+	sut := NewSimpleCache[string](time.Millisecond)
+	defer sut.Close()
+	events := sut.Subscribe()
+
+	sut.SetWithTTL("key1", "value1", -time.Second)
+	<-events
+	select {
+	case e := <-events:
+		if e.Key != "key1" || e.Type != EventExpire {
+			t.Errorf("Expected an expire event for key1, got %v", e)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected the janitor to publish an expire event")
+	}
+}
+
+func TestSimpleCache_SubscribeDropsWhenFull(t *testing.T) {
+	sut := NewSimpleCache[int](0)
+	defer sut.Close()
+	events := sut.Subscribe()
+
+	for i := 0; i < subscriberBuffer+10; i++ {
+		sut.Set("key1", i)
+	}
+	if n := len(receiveEvents(events)); n != subscriberBuffer {
+		t.Errorf("Expected %d buffered events, got %d", subscriberBuffer, n)
+	}
+	if n := sut.Stats().DroppedEvents; n != 10 {
+		t.Errorf("Expected 10 dropped events, got %d", n)
+	}
+}
+
+func TestSimpleCache_Unsubscribe(t *testing.T) {
+	sut := NewSimpleCache[string](0)
+	defer sut.Close()
+	events := sut.Subscribe()
+	other := sut.Subscribe()
+
+	sut.Set("key1", "value1")
+	sut.Unsubscribe(events)
+	sut.Unsubscribe(events)
+	sut.Set("key2", "value2")
+
+	if e, ok := <-events; !ok || e.Key != "key1" {
+		t.Errorf("Expected the buffered event for key1, got %v, ok: %v", e, ok)
+	}
+	if _, ok := <-events; ok {
+		t.Errorf("Expected the channel to be closed after Unsubscribe")
+	}
+	if n := len(receiveEvents(other)); n != 2 {
+		t.Errorf("Expected the other subscriber to keep receiving events, got %d", n)
+	}
+}
+
+func TestEventType_String(t *testing.T) {
+	for typ, want := range map[EventType]string{
+		EventSet:     "set",
+		EventDelete:  "delete",
+		EventExpire:  "expire",
+		EventEvict:   "evict",
+		EventType(0): "unknown",
+	} {
+		if got := typ.String(); got != want {
+			t.Errorf("Expected %d to be %q, got %q", typ, want, got)
+		}
+	}
+}
+
+func TestSimpleCache_EventsFollowChangeOrder(t *testing.T) {
+	sut := NewSimpleCache[int](0)
+	sut.Set("key1", 1)
+	sut.Set("key2", 2)
+	events := sut.Subscribe()
+
+	// The callback for key1 stores key2 again before DeleteMany has reported
+	// key2's removal, so key2's delete event must already have been sent.
+	sut.OnEvicted(func(key string, _ int, _ EvictionReason) {
+		if key == "key1" {
+			sut.Set("key2", 3)
+		}
+	})
+	sut.DeleteMany([]string{"key1", "key2"})
+	sut.OnEvicted(nil)
+
+	var got []string
+	for _, e := range receiveEvents(events) {
+		got = append(got, e.Type.String()+" "+e.Key)
+	}
+	if !sut.Has("key2") || fmt.Sprint(got) != "[delete key1 delete key2 set key2]" {
+		t.Errorf("Expected key2's set to follow its delete, got %v", got)
+	}
+}
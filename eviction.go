@@ -0,0 +1,303 @@
+package keyvalstore
+
+import (
+	"container/list"
+	"math"
+)
+
+// evictionPolicy decides which entry to remove when a bounded shard is full.
+// Implementations are not safe for concurrent use; the cache calls them with
+// the shard's write lock held.
+type evictionPolicy[K comparable] interface {
+	// add records a newly inserted key.
+	add(key K)
+	// update records that an existing key was overwritten.
+	update(key K)
+	// access records that a key was read.
+	access(key K)
+	// remove forgets key. It is a no-op for unknown keys.
+	remove(key K)
+	// victim returns the key that should be evicted next, never returning
+	// protect if it is non-nil.
+	victim(protect *K) (K, bool)
+	// reset forgets all keys.
+	reset()
+}
+
+// policyKind selects the eviction policy of a bounded cache.
+type policyKind int
+
+const (
+	noPolicy policyKind = iota
+	lruPolicyKind
+	lfuPolicyKind
+	fifoPolicyKind
+)
+
+// newPolicy creates an eviction policy of kind p, or returns nil for noPolicy.
+func newPolicy[K comparable](p policyKind) evictionPolicy[K] {
+	switch p {
+	case lruPolicyKind:
+		return newLRUPolicy[K]()
+	case lfuPolicyKind:
+		return newLFUPolicy[K]()
+	case fifoPolicyKind:
+		return newFIFOPolicy[K]()
+	default:
+		return nil
+	}
+}
+
+// evictOverflow removes entries chosen by shard s's eviction policy until the
+// shard is within its entry, cost and byte limits, returning the removed entries.
+// If protect is non-nil that key is never evicted, so an entry that was just
+// stored cannot be its own victim. The caller must hold s's write lock.
+func (c *Cache[K, V]) evictOverflow(s *shard[K, V], protect *K) []evictedEntry[K, V] {
+	var evicted []evictedEntry[K, V]
+	for s.overCapacity() {
+		key, ok := s.policy.victim(protect)
+		if !ok {
+			break
+		}
+		item, _ := s.removeLocked(key)
+		c.stats.evictions.Add(1)
+		if !item.negative {
+			evicted = c.removedLocked(evicted, key, item.value, ReasonCapacity)
+		}
+	}
+	return evicted
+}
+
+// Resize changes the maximum number of entries of a cache created with
+// WithMaxEntries, WithLFU or WithFIFO, or bounded by WithMaxCost or
+// WithMaxBytes, to newMax. Shrinking evicts entries chosen by the cache's
+// eviction policy until it fits, reporting each to the eviction callback with
+// ReasonCapacity; growing only raises the limit. It returns the number of
+// entries evicted. As with WithShards, the limit is divided evenly between
+// shards. Resize is a no-op returning 0 if newMax is less than 1 or the cache
+// has no eviction policy, as an unbounded cache does not track the order in
+// which to evict.
+func (c *Cache[K, V]) Resize(newMax int) int {
+	if newMax < 1 || c.policy == noPolicy {
+		return 0
+	}
+	c.janitorMutex.Lock()
+	c.maxEntries = newMax
+	c.janitorMutex.Unlock()
+
+	n := 0
+	var evicted []evictedEntry[K, V]
+	for i, s := range c.shards {
+		s.mutex.Lock()
+		s.maxEntries = shareOf(newMax, c.shardCount, i)
+		before := len(s.data)
+		evicted = append(evicted, c.evictOverflow(s, nil)...)
+		n += before - len(s.data)
+		s.mutex.Unlock()
+	}
+
+	c.notifyEvicted(evicted)
+	return n
+}
+
+func (s *shard[K, V]) overCapacity() bool {
+	return (s.maxEntries > 0 && len(s.data) > s.maxEntries) ||
+		(s.maxCost > 0 && s.totalCost > s.maxCost) ||
+		(s.maxBytes > 0 && s.totalBytes > s.maxBytes)
+}
+
+// lruPolicy evicts the least recently read or written key.
+type lruPolicy[K comparable] struct {
+	// order holds keys from most (front) to least (back) recently used.
+	order    *list.List
+	elements map[K]*list.Element
+}
+
+func newLRUPolicy[K comparable]() *lruPolicy[K] {
+	return &lruPolicy[K]{
+		order:    list.New(),
+		elements: make(map[K]*list.Element),
+	}
+}
+
+func (p *lruPolicy[K]) add(key K) {
+	p.elements[key] = p.order.PushFront(key)
+}
+
+func (p *lruPolicy[K]) update(key K) {
+	p.access(key)
+}
+
+func (p *lruPolicy[K]) access(key K) {
+	if e, ok := p.elements[key]; ok {
+		p.order.MoveToFront(e)
+	}
+}
+
+func (p *lruPolicy[K]) remove(key K) {
+	if e, ok := p.elements[key]; ok {
+		p.order.Remove(e)
+		delete(p.elements, key)
+	}
+}
+
+func (p *lruPolicy[K]) victim(protect *K) (K, bool) {
+	for e := p.order.Back(); e != nil; e = e.Prev() {
+		if key := e.Value.(K); protect == nil || key != *protect {
+			return key, true
+		}
+	}
+	var zero K
+	return zero, false
+}
+
+func (p *lruPolicy[K]) reset() {
+	p.order.Init()
+	clear(p.elements)
+}
+
+// fifoPolicy evicts the key that was inserted first. It keeps keys in insertion
+// order in an lruPolicy that is never told about reads or overwrites.
+type fifoPolicy[K comparable] struct {
+	*lruPolicy[K]
+}
+
+func newFIFOPolicy[K comparable]() fifoPolicy[K] {
+	return fifoPolicy[K]{newLRUPolicy[K]()}
+}
+
+func (fifoPolicy[K]) update(K) {}
+
+func (fifoPolicy[K]) access(K) {}
+
+// lfuMaxFrequency caps access counters so long-lived hot keys cannot overflow.
+const lfuMaxFrequency = math.MaxUint16
+
+// lfuPolicy evicts the least frequently used key, breaking ties by evicting
+// the key that reached its frequency first.
+//
+// Keys are grouped in buckets of equal frequency kept in ascending order, so
+// every operation runs in constant time.
+type lfuPolicy[K comparable] struct {
+	buckets *list.List // of *lfuBucket, lowest frequency at the front
+	entries map[K]*lfuEntry[K]
+}
+
+type lfuBucket struct {
+	freq uint16
+	keys *list.List // of *lfuEntry[K], oldest at the front
+}
+
+type lfuEntry[K comparable] struct {
+	key    K
+	bucket *list.Element // in lfuPolicy.buckets
+	elem   *list.Element // in lfuBucket.keys
+}
+
+func newLFUPolicy[K comparable]() *lfuPolicy[K] {
+	return &lfuPolicy[K]{
+		buckets: list.New(),
+		entries: make(map[K]*lfuEntry[K]),
+	}
+}
+
+func (p *lfuPolicy[K]) add(key K) {
+	front := p.buckets.Front()
+	if front == nil || front.Value.(*lfuBucket).freq != 1 {
+		front = p.buckets.PushFront(&lfuBucket{freq: 1, keys: list.New()})
+	}
+	e := &lfuEntry[K]{key: key, bucket: front}
+	e.elem = front.Value.(*lfuBucket).keys.PushBack(e)
+	p.entries[key] = e
+}
+
+func (p *lfuPolicy[K]) update(key K) {
+	p.access(key)
+}
+
+func (p *lfuPolicy[K]) access(key K) {
+	e, ok := p.entries[key]
+	if !ok {
+		return
+	}
+	cur := e.bucket.Value.(*lfuBucket)
+	if cur.freq == lfuMaxFrequency {
+		cur.keys.MoveToBack(e.elem)
+		return
+	}
+
+	next := e.bucket.Next()
+	if next == nil || next.Value.(*lfuBucket).freq != cur.freq+1 {
+		next = p.buckets.InsertAfter(&lfuBucket{freq: cur.freq + 1, keys: list.New()}, e.bucket)
+	}
+	p.unlink(e)
+	e.bucket = next
+	e.elem = next.Value.(*lfuBucket).keys.PushBack(e)
+}
+
+func (p *lfuPolicy[K]) remove(key K) {
+	if e, ok := p.entries[key]; ok {
+		p.unlink(e)
+		delete(p.entries, key)
+	}
+}
+
+// unlink removes e from its bucket, dropping the bucket once it is empty.
+func (p *lfuPolicy[K]) unlink(e *lfuEntry[K]) {
+	b := e.bucket.Value.(*lfuBucket)
+	b.keys.Remove(e.elem)
+	if b.keys.Len() == 0 {
+		p.buckets.Remove(e.bucket)
+	}
+}
+
+func (p *lfuPolicy[K]) victim(protect *K) (K, bool) {
+	for b := p.buckets.Front(); b != nil; b = b.Next() {
+		for e := b.Value.(*lfuBucket).keys.Front(); e != nil; e = e.Next() {
+			if key := e.Value.(*lfuEntry[K]).key; protect == nil || key != *protect {
+				return key, true
+			}
+		}
+	}
+	var zero K
+	return zero, false
+}
+
+// decay halves every access count, keeping counts of at least one, so that
+// keys that were hot long ago do not outrank those in use now. Keys whose
+// counts become equal keep their relative order, those that were less
+// frequent coming first.
+func (p *lfuPolicy[K]) decay() {
+	for b := p.buckets.Front(); b != nil; {
+		next := b.Next()
+		bucket := b.Value.(*lfuBucket)
+		bucket.freq = max(bucket.freq/2, 1)
+		if prev := b.Prev(); prev != nil && prev.Value.(*lfuBucket).freq == bucket.freq {
+			into := prev.Value.(*lfuBucket).keys
+			for e := bucket.keys.Front(); e != nil; e = e.Next() {
+				entry := e.Value.(*lfuEntry[K])
+				entry.bucket = prev
+				entry.elem = into.PushBack(entry)
+			}
+			p.buckets.Remove(b)
+		}
+		b = next
+	}
+}
+
+// decayFrequencies halves the LFU access counts of shard s if the cache has a
+// frequency decay.
+func (c *Cache[K, V]) decayFrequencies(s *shard[K, V]) {
+	p, ok := s.policy.(*lfuPolicy[K])
+	if !ok {
+		return
+	}
+	s.mutex.Lock()
+	p.decay()
+	s.mutex.Unlock()
+}
+
+func (p *lfuPolicy[K]) reset() {
+	p.buckets.Init()
+	clear(p.entries)
+}
@@ -0,0 +1,350 @@
+package keyvalstore
+
+import (
+	"errors"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestSimpleCache_LRUEvictionOrder(t *testing.T) {
+	sut := NewSimpleCache(1*time.Minute, WithMaxEntries[string](3))
+	defer sut.Close()
+
+	sut.Set("a", "1")
+	sut.Set("b", "2")
+	sut.Set("c", "3")
+	sut.Get("a")      // recency: a, c, b
+	sut.Set("d", "4") // evicts b
+	sut.Get("c")      // recency: c, d, a
+	sut.Set("e", "5") // evicts a
+
+	keys := sut.Keys()
+	sort.Strings(keys)
+	if len(keys) != 3 || keys[0] != "c" || keys[1] != "d" || keys[2] != "e" {
+		t.Errorf("Expected keys [c d e] after LRU eviction, got %v", keys)
+	}
+}
+
+func TestSimpleCache_LRUOverwriteDoesNotEvict(t *testing.T) {
+	sut := NewSimpleCache(1*time.Minute, WithMaxEntries[string](2))
+	defer sut.Close()
+
+	sut.Set("a", "1")
+	sut.Set("b", "2")
+	sut.Set("a", "3") // recency: a, b
+	if n := sut.Len(); n != 2 {
+		t.Errorf("Expected overwriting a key to keep length 2, got %d", n)
+	}
+
+	sut.Delete("b")
+	sut.Set("c", "4")
+	sut.Set("d", "5") // evicts a
+	if sut.Has("a") {
+		t.Errorf("Expected a to be evicted as least recently used")
+	}
+	if !sut.Has("c") || !sut.Has("d") {
+		t.Errorf("Expected c and d to be present, got keys %v", sut.Keys())
+	}
+}
+
+func TestSimpleCache_LFUEvictsLeastFrequentlyUsed(t *testing.T) {
+	sut := NewSimpleCache(1*time.Minute, WithLFU[string](3))
+	defer sut.Close()
+
+	sut.Set("a", "1")
+	sut.Set("b", "2")
+	sut.Set("c", "3")
+	for i := 0; i < 3; i++ {
+		sut.Get("a")
+	}
+	sut.Get("b")
+	sut.Get("c")
+	sut.Get("c")
+
+	sut.Set("d", "4") // evicts b, the least frequently used
+	if sut.Has("b") {
+		t.Errorf("Expected b to be evicted as least frequently used")
+	}
+
+	sut.Set("e", "5") // evicts d, which has only been written once
+	if sut.Has("d") {
+		t.Errorf("Expected d to be evicted as least frequently used")
+	}
+
+	keys := sut.Keys()
+	sort.Strings(keys)
+	if len(keys) != 3 || keys[0] != "a" || keys[1] != "c" || keys[2] != "e" {
+		t.Errorf("Expected keys [a c e] after LFU eviction, got %v", keys)
+	}
+}
+
+func TestSimpleCache_LFUBreaksTiesByOldest(t *testing.T) {
+	sut := NewSimpleCache(1*time.Minute, WithLFU[string](2))
+	defer sut.Close()
+
+	sut.Set("a", "1")
+	sut.Set("b", "2")
+	sut.Set("c", "3") // a and b are tied; a is older
+
+	if sut.Has("a") {
+		t.Errorf("Expected a to be evicted as the oldest of equally used keys")
+	}
+	if !sut.Has("b") || !sut.Has("c") {
+		t.Errorf("Expected b and c to be present, got keys %v", sut.Keys())
+	}
+}
+
+func TestLFUPolicy_FrequencySaturates(t *testing.T) {
+	p := newLFUPolicy[string]()
+	p.add("hot")
+	for i := 0; i < lfuMaxFrequency+10; i++ {
+		p.access("hot")
+	}
+	if freq := p.entries["hot"].bucket.Value.(*lfuBucket).freq; freq != lfuMaxFrequency {
+		t.Errorf("Expected frequency to saturate at %d, got %d", lfuMaxFrequency, freq)
+	}
+}
+
+func TestLFUPolicy_Decay(t *testing.T) {
+	p := newLFUPolicy[string]()
+	for key, reads := range map[string]int{"a": 0, "b": 1, "c": 2, "d": 7} {
+		p.add(key)
+		for range reads {
+			p.access(key)
+		}
+	}
+
+	p.decay()
+	for key, want := range map[string]uint16{"a": 1, "b": 1, "c": 1, "d": 4} {
+		if freq := p.entries[key].bucket.Value.(*lfuBucket).freq; freq != want {
+			t.Errorf("Expected %s to decay to frequency %d, got %d", key, want, freq)
+		}
+	}
+	if n := p.buckets.Len(); n != 2 {
+		t.Errorf("Expected equal frequencies to share a bucket, got %d buckets", n)
+	}
+	for _, want := range []string{"a", "b", "c", "d"} {
+		if key, _ := p.victim(nil); key != want {
+			t.Errorf("Expected victim %s, got %s", want, key)
+		}
+		p.remove(want)
+	}
+}
+
+func TestSimpleCache_WithFrequencyDecay(t *testing.T) {
+	sut := NewSimpleCache(time.Millisecond, WithLFU[string](2), WithFrequencyDecay[string](time.Millisecond))
+	defer sut.Close()
+
+	sut.Set("once-hot", "1")
+	for range 100 {
+		sut.Get("once-hot")
+	}
+	sut.Set("recent", "2")
+
+	s := sut.shards[0]
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		s.mutex.RLock()
+		freq := s.policy.(*lfuPolicy[string]).entries["once-hot"].bucket.Value.(*lfuBucket).freq
+		s.mutex.RUnlock()
+		if freq == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	sut.Get("recent")
+	sut.Get("recent")
+
+	sut.Set("new", "3")
+	if sut.Has("once-hot") {
+		t.Errorf("Expected the decayed key to be evicted before the recently used one")
+	}
+	if !sut.Has("recent") {
+		t.Errorf("Expected the recently used key to be kept")
+	}
+}
+
+func TestSimpleCache_FIFOIgnoresReads(t *testing.T) {
+	sut := NewSimpleCache(1*time.Minute, WithFIFO[string](2))
+	defer sut.Close()
+
+	sut.Set("old", "1")
+	sut.Set("new", "2")
+	for range 10 {
+		sut.Get("old")
+	}
+	sut.GetOrSet("old", "ignored", time.Minute)
+	sut.Set("newest", "3") // evicts old despite its reads
+
+	if sut.Has("old") {
+		t.Errorf("Expected the oldest entry to be evicted first, however often it was read")
+	}
+	if !sut.Has("new") || !sut.Has("newest") {
+		t.Errorf("Expected new and newest to be present, got keys %v", sut.Keys())
+	}
+	if sut.readsMutate() {
+		t.Errorf("Expected FIFO eviction to keep Get on the read lock")
+	}
+}
+
+func TestSimpleCache_FIFOOverwriteKeepsPosition(t *testing.T) {
+	sut := NewSimpleCache(1*time.Minute, WithFIFO[string](2))
+	defer sut.Close()
+
+	sut.Set("a", "1")
+	sut.Set("b", "2")
+	sut.Set("a", "3") // a stays first in line
+	sut.Set("c", "4") // evicts a
+
+	keys := sut.Keys()
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "b" || keys[1] != "c" {
+		t.Errorf("Expected keys [b c] after FIFO eviction, got %v", keys)
+	}
+}
+
+func TestSimpleCache_MaxCostEvictsUntilItemFits(t *testing.T) {
+	byLength := func(v string) int64 { return int64(len(v)) }
+	sut := NewSimpleCache(1*time.Minute, WithMaxCost(10, byLength))
+	defer sut.Close()
+
+	sut.Set("a", "aaaa")
+	sut.Set("b", "bbbb")
+	sut.Get("a")               // recency: a, b
+	if !sut.Set("c", "cccc") { // total 12: evicts b
+		t.Errorf("Expected c to be admitted")
+	}
+	if sut.Has("b") {
+		t.Errorf("Expected b to be evicted as least recently used")
+	}
+	if !sut.Has("a") || !sut.Has("c") {
+		t.Errorf("Expected a and c to be present, got keys %v", sut.Keys())
+	}
+
+	if !sut.Set("d", "dddddddddd") { // needs the whole budget
+		t.Errorf("Expected d to be admitted")
+	}
+	if keys := sut.Keys(); len(keys) != 1 || keys[0] != "d" {
+		t.Errorf("Expected only d to remain, got %v", keys)
+	}
+}
+
+func TestSimpleCache_MaxCostRejectsOversizedItem(t *testing.T) {
+	byLength := func(v string) int64 { return int64(len(v)) }
+	sut := NewSimpleCache(1*time.Minute, WithMaxCost(5, byLength))
+	defer sut.Close()
+
+	sut.Set("a", "aaa")
+	if sut.Set("a", "too large") {
+		t.Errorf("Expected an item costlier than the maximum to be rejected")
+	}
+	val, found := sut.Get("a")
+	if !found || val != "aaa" {
+		t.Errorf("Expected a rejected Set to leave 'aaa' in place, got '%s', found: %v", val, found)
+	}
+
+	if err := sut.Store("b", "too large", time.Minute); !errors.Is(err, ErrItemTooLarge) {
+		t.Errorf("Expected Store to return ErrItemTooLarge, got %v", err)
+	}
+	if err := sut.Store("b", "bb", time.Minute); err != nil {
+		t.Errorf("Expected Store to accept an item within the maximum, got %v", err)
+	}
+}
+
+func TestSimpleCache_ResizeShrinks(t *testing.T) {
+	sut := NewSimpleCache(1*time.Minute, WithMaxEntries[string](4))
+	defer sut.Close()
+
+	var evicted []string
+	sut.OnEvicted(func(key string, _ string, reason EvictionReason) {
+		evicted = append(evicted, key+":"+reason.String())
+	})
+	sut.Set("a", "1")
+	sut.Set("b", "2")
+	sut.Set("c", "3")
+	sut.Set("d", "4")
+	sut.Get("a") // recency: a, d, c, b
+
+	if n := sut.Resize(2); n != 2 {
+		t.Errorf("Expected shrinking to 2 to evict 2 entries, got %d", n)
+	}
+	keys := sut.Keys()
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "a" || keys[1] != "d" {
+		t.Errorf("Expected the least recently used entries to go, leaving [a d], got %v", keys)
+	}
+	if len(evicted) != 2 || evicted[0] != "b:capacity" || evicted[1] != "c:capacity" {
+		t.Errorf("Expected b and c to be reported as capacity evictions, got %v", evicted)
+	}
+
+	sut.Set("e", "5") // the new limit applies to later writes
+	if n := sut.Len(); n != 2 {
+		t.Errorf("Expected the cache to stay within the new limit, got %d entries", n)
+	}
+}
+
+func TestSimpleCache_ResizeGrows(t *testing.T) {
+	sut := NewSimpleCache(1*time.Minute, WithFIFO[string](2))
+	defer sut.Close()
+
+	sut.Set("a", "1")
+	sut.Set("b", "2")
+	if n := sut.Resize(3); n != 0 {
+		t.Errorf("Expected growing not to evict, got %d", n)
+	}
+	sut.Set("c", "3")
+	if n := sut.Len(); n != 3 {
+		t.Errorf("Expected room for 3 entries after growing, got %d", n)
+	}
+}
+
+func TestSimpleCache_ResizeIgnoresInvalidSizes(t *testing.T) {
+	bounded := NewSimpleCache(1*time.Minute, WithMaxEntries[string](2))
+	defer bounded.Close()
+	bounded.Set("a", "1")
+	if n := bounded.Resize(0); n != 0 || bounded.maxEntries != 2 {
+		t.Errorf("Expected Resize(0) to be a no-op")
+	}
+
+	unbounded := NewSimpleCache[string](1 * time.Minute)
+	defer unbounded.Close()
+	unbounded.Set("a", "1")
+	unbounded.Set("b", "2")
+	if n := unbounded.Resize(1); n != 0 || unbounded.Len() != 2 {
+		t.Errorf("Expected Resize to leave an unbounded cache untouched")
+	}
+}
+
+func TestSimpleCache_TrySet(t *testing.T) {
+	sut := NewSimpleCache(1*time.Minute, WithMaxEntries[string](2))
+	defer sut.Close()
+
+	if key, evicted := sut.TrySet("a", "1", time.Minute); evicted {
+		t.Errorf("Expected no eviction below the limit, got %s", key)
+	}
+	sut.TrySet("b", "2", time.Minute)
+	if key, evicted := sut.TrySet("b", "3", time.Minute); evicted {
+		t.Errorf("Expected overwriting a key not to evict, got %s", key)
+	}
+	key, evicted := sut.TrySet("c", "4", time.Minute)
+	if !evicted || key != "a" {
+		t.Errorf("Expected a to be evicted to make room for c, got '%s', evicted: %v", key, evicted)
+	}
+	if !sut.Has("c") || sut.Has("a") {
+		t.Errorf("Expected c to be stored in place of a, got keys %v", sut.Keys())
+	}
+}
+
+func TestSimpleCache_TrySetRejected(t *testing.T) {
+	sut := NewSimpleCache(1*time.Minute, WithMaxCost(3, func(v string) int64 { return int64(len(v)) }))
+	defer sut.Close()
+
+	sut.Set("a", "1")
+	if key, evicted := sut.TrySet("b", "too large", time.Minute); evicted || key != "" {
+		t.Errorf("Expected a rejected value to evict nothing, got '%s', evicted: %v", key, evicted)
+	}
+	if sut.Has("b") || !sut.Has("a") {
+		t.Errorf("Expected the rejected value not to be stored")
+	}
+}
@@ -0,0 +1,89 @@
+package keyvalstore
+
+import (
+	"container/heap"
+	"time"
+)
+
+// expiryEntry is a key scheduled to expire at a given time.
+type expiryEntry[K comparable] struct {
+	key   K
+	at    time.Time
+	index int
+}
+
+// expiryQueue is a min-heap of keys ordered by expiry time, so the janitor only
+// visits entries that are actually due. Each key has at most one entry, which
+// is rescheduled in place when its expiry changes. Keys that never expire are
+// not tracked.
+type expiryQueue[K comparable] struct {
+	entries []*expiryEntry[K]
+	byKey   map[K]*expiryEntry[K]
+}
+
+func newExpiryQueue[K comparable]() *expiryQueue[K] {
+	return &expiryQueue[K]{byKey: make(map[K]*expiryEntry[K])}
+}
+
+// schedule sets the expiry of key to at, or stops tracking key if at is zero.
+func (q *expiryQueue[K]) schedule(key K, at time.Time) {
+	e, tracked := q.byKey[key]
+	switch {
+	case at.IsZero():
+		q.remove(key)
+	case tracked:
+		e.at = at
+		heap.Fix(q, e.index)
+	default:
+		e = &expiryEntry[K]{key: key, at: at}
+		heap.Push(q, e)
+		q.byKey[key] = e
+	}
+}
+
+// remove stops tracking key. It is a no-op for unknown keys.
+func (q *expiryQueue[K]) remove(key K) {
+	if e, tracked := q.byKey[key]; tracked {
+		heap.Remove(q, e.index)
+		delete(q.byKey, key)
+	}
+}
+
+// next returns the entry that expires soonest.
+func (q *expiryQueue[K]) next() (*expiryEntry[K], bool) {
+	if len(q.entries) == 0 {
+		return nil, false
+	}
+	return q.entries[0], true
+}
+
+func (q *expiryQueue[K]) reset() {
+	q.entries = nil
+	clear(q.byKey)
+}
+
+// Len, Less, Swap, Push and Pop implement heap.Interface.
+
+func (q *expiryQueue[K]) Len() int { return len(q.entries) }
+
+func (q *expiryQueue[K]) Less(i, j int) bool { return q.entries[i].at.Before(q.entries[j].at) }
+
+func (q *expiryQueue[K]) Swap(i, j int) {
+	q.entries[i], q.entries[j] = q.entries[j], q.entries[i]
+	q.entries[i].index = i
+	q.entries[j].index = j
+}
+
+func (q *expiryQueue[K]) Push(x any) {
+	e := x.(*expiryEntry[K])
+	e.index = len(q.entries)
+	q.entries = append(q.entries, e)
+}
+
+func (q *expiryQueue[K]) Pop() any {
+	last := len(q.entries) - 1
+	e := q.entries[last]
+	q.entries[last] = nil
+	q.entries = q.entries[:last]
+	return e
+}
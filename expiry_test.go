@@ -0,0 +1,110 @@
+package keyvalstore
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestExpiryQueue_OrdersByExpiry(t *testing.T) {
+	q := newExpiryQueue[string]()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	q.schedule("c", base.Add(3*time.Second))
+	q.schedule("a", base.Add(1*time.Second))
+	q.schedule("b", base.Add(2*time.Second))
+	q.schedule("a", base.Add(4*time.Second)) // rescheduled in place
+	q.schedule("never", time.Time{})
+	q.remove("b")
+
+	if q.Len() != 2 {
+		t.Fatalf("Expected 2 tracked keys, got %d", q.Len())
+	}
+	for _, want := range []string{"c", "a"} {
+		e, _ := q.next()
+		if e.key != want {
+			t.Errorf("Expected %s to expire next, got %s", want, e.key)
+		}
+		q.remove(e.key)
+	}
+	if _, ok := q.next(); ok {
+		t.Errorf("Expected the queue to be empty")
+	}
+}
+
+func TestSimpleCache_JanitorRespectsRescheduledExpiry(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithClock[string](clock))
+
+	sut.SetWithTTL("touched", "value1", time.Minute)
+	sut.SetWithTTL("replaced", "value2", time.Minute)
+	sut.SetWithTTL("expiring", "value3", time.Minute)
+	sut.Touch("touched", time.Hour)
+	sut.Replace("replaced", "value4", time.Hour)
+
+	clock.Advance(2 * time.Minute)
+	sut.sweep(false)
+
+	keys := sut.Keys()
+	if len(keys) != 2 || sut.Has("expiring") {
+		t.Errorf("Expected only the rescheduled keys to survive the sweep, got %v", keys)
+	}
+	if n := sut.shards[0].expiries.Len(); n != 2 {
+		t.Errorf("Expected 2 keys left in the expiry queue, got %d", n)
+	}
+}
+
+// populateForSweep fills a cache with n entries of which due have expired.
+func populateForSweep(c *SimpleCache[int], clock *fakeClock, n, due int) {
+	for i := 0; i < n-due; i++ {
+		c.SetWithTTL(fmt.Sprintf("live%d", i), i, time.Hour)
+	}
+	for i := 0; i < due; i++ {
+		c.SetWithTTL(fmt.Sprintf("due%d", i), i, time.Second)
+	}
+	clock.Advance(time.Minute)
+}
+
+func BenchmarkReapExpired(b *testing.B) {
+	const workingSet, due = 100_000, 100
+	clock := newFakeClock()
+	c := NewSimpleCache(0, WithClock[int](clock))
+	populateForSweep(c, clock, workingSet, due)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		for j := 0; j < due; j++ {
+			c.SetWithTTL(fmt.Sprintf("due%d", j), j, -time.Second)
+		}
+		b.StartTimer()
+		c.sweep(false)
+	}
+}
+
+// BenchmarkReapExpiredFullScan measures the previous janitor strategy of
+// scanning the whole map on every sweep, for comparison.
+func BenchmarkReapExpiredFullScan(b *testing.B) {
+	const workingSet, due = 100_000, 100
+	clock := newFakeClock()
+	c := NewSimpleCache(0, WithClock[int](clock))
+	populateForSweep(c, clock, workingSet, due)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		for j := 0; j < due; j++ {
+			c.SetWithTTL(fmt.Sprintf("due%d", j), j, -time.Second)
+		}
+		b.StartTimer()
+		now := c.clock.Now()
+		s := c.shards[0]
+		s.mutex.Lock()
+		for k, it := range s.data {
+			if it.expired(now) {
+				s.removeLocked(k)
+			}
+		}
+		s.mutex.Unlock()
+	}
+}
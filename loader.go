@@ -0,0 +1,482 @@
+package keyvalstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+)
+
+var errLoaderPanicked = fmt.Errorf("%w: loader panicked", ErrLoaderFailed)
+
+// loadCall is a loader invocation shared by concurrent GetOrLoad callers.
+type loadCall[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// GetOrLoad returns the live value stored under key, or calls loader to
+// produce it and stores the result with the given ttl. Concurrent calls for the
+// same key share a single loader invocation and all receive its result.
+// If loader returns an error, nothing is cached and the error, wrapped in
+// ErrLoaderFailed, is returned to every waiting caller, unless negative caching
+// is enabled and the error matches ErrNotFound (see WithNegativeCaching).
+//
+// With WithRefreshAhead, a value found close to its expiry is returned at once
+// while loader refreshes it in the background. With WithCircuitBreaker, a miss
+// returns ErrCircuitOpen without calling loader while the circuit is open.
+func (c *Cache[K, V]) GetOrLoad(key K, ttl time.Duration, loader func() (V, error)) (V, error) {
+	return c.GetOrLoadContext(context.Background(), key, ttl, func(context.Context) (V, error) {
+		return loader()
+	})
+}
+
+// GetOrLoadContext is like GetOrLoad, but passes ctx to loader and gives up
+// once ctx is done, returning ctx's error: immediately if ctx is already done,
+// when waiting for another caller's load of the same key, or when loader
+// returns after ctx was cancelled. An abandoned wait does not cancel the load
+// it was waiting for, which keeps running for its other callers. Refreshes
+// started by WithRefreshAhead receive a context that is not cancelled with ctx.
+func (c *Cache[K, V]) GetOrLoadContext(ctx context.Context, key K, ttl time.Duration, loader func(ctx context.Context) (V, error)) (V, error) {
+	return c.getOrLoad(ctx, key, func(ctx context.Context) (V, time.Duration, error) {
+		v, err := loader(ctx)
+		return v, ttl, err
+	})
+}
+
+// GetOrLoadMany returns the live values stored under keys, calling loader once
+// with the keys that are missing to produce the rest, and stores what it
+// returns with the given ttl. Keys that are being loaded by another GetOrLoad
+// or GetOrLoadMany call are not passed to loader; GetOrLoadMany waits for those
+// loads instead, so concurrent calls with overlapping keys load each key once.
+//
+// Keys that loader leaves out of its result, or all of them if it returns an
+// error matching ErrNotFound, are treated as not found: they are missing from
+// the returned map and, with WithNegativeCaching, cached as misses. Values for
+// keys that were not asked for are ignored. If loader returns an error, none of
+// its values are stored and the error, wrapped in ErrLoaderFailed, is returned
+// along with the values that could be found without it. WithCircuitBreaker,
+// WithMaxConcurrentLoads and WithLoaderRetry apply to the batch as a whole.
+func (c *Cache[K, V]) GetOrLoadMany(keys []K, ttl time.Duration, loader func(missing []K) (map[K]V, error)) (map[K]V, error) {
+	result := make(map[K]V, len(keys))
+	seen := make(map[K]struct{}, len(keys))
+	var missing []K
+	for _, key := range keys {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		if v, ok := c.get(key); ok {
+			result[key] = v
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+	if c.closed.Load() {
+		return result, ErrClosed
+	}
+
+	var owned []K
+	var calls []*loadCall[V]
+	waiting := make(map[K]*loadCall[V])
+	c.loadMutex.Lock()
+	for _, key := range missing {
+		if call, ok := c.loads[key]; ok {
+			waiting[key] = call
+			continue
+		}
+		if v, ok := c.Peek(key); ok {
+			result[key] = v
+			continue
+		}
+		if c.negativeTTL > 0 && c.cachedMiss(key) {
+			continue
+		}
+		owned = append(owned, key)
+		calls = append(calls, c.registerLoadLocked(key))
+	}
+	c.loadMutex.Unlock()
+
+	var err error
+	if len(owned) > 0 {
+		err = c.loadMany(owned, calls, ttl, loader)
+		for i, key := range owned {
+			if calls[i].err == nil {
+				result[key] = calls[i].value
+			}
+		}
+	}
+	for key, call := range waiting {
+		<-call.done
+		switch {
+		case call.err == nil:
+			result[key] = c.copyValue(call.value)
+		case !errors.Is(call.err, ErrNotFound) && err == nil:
+			err = call.err
+		}
+	}
+	return result, err
+}
+
+// loadMany calls loader with keys, whose in-flight loads are calls, stores
+// the values it returns and releases the waiters of every call, even if loader
+// panics. It returns the loader's error, wrapped in ErrLoaderFailed.
+func (c *Cache[K, V]) loadMany(keys []K, calls []*loadCall[V], ttl time.Duration, loader func(missing []K) (map[K]V, error)) (err error) {
+	completed := false
+	defer func() {
+		if !completed {
+			err = errLoaderPanicked
+		}
+		c.loadMutex.Lock()
+		for i, key := range keys {
+			if !completed {
+				calls[i].err = err
+			}
+			delete(c.loads, key)
+		}
+		c.loadMutex.Unlock()
+		for _, call := range calls {
+			close(call.done)
+		}
+	}()
+
+	ctx := context.Background()
+	probe, done, err := c.startLoad(ctx)
+	if err != nil {
+		for _, call := range calls {
+			call.err = err
+		}
+		completed = true
+		return err
+	}
+	defer done()
+
+	var loaded map[K]V
+	_, _, err = c.callLoader(probe, c.retrying(ctx, func() (V, time.Duration, error) {
+		var zero V
+		var err error
+		loaded, err = loader(slices.Clone(keys))
+		return zero, ttl, err
+	}))
+	switch {
+	case errors.Is(err, ErrNotFound):
+		loaded, err = nil, nil
+	case err == errLoaderPanicked:
+	case err != nil:
+		err = fmt.Errorf("%w: %w", ErrLoaderFailed, err)
+	}
+	for i, key := range keys {
+		v, ok := loaded[key]
+		switch {
+		case err != nil:
+			calls[i].err = err
+		case !ok:
+			calls[i].err = ErrNotFound
+			if c.negativeTTL > 0 {
+				c.storeMiss(key)
+			}
+		default:
+			c.SetWithTTL(key, v, ttl)
+			calls[i].value = v
+		}
+	}
+	completed = true
+	return err
+}
+
+// Fetch returns the live value stored under key, or loads it with the loader
+// set by WithLoader, as GetOrLoadContext does with a loader of its own. Unlike
+// Get, it reports why a load failed. Without WithLoader, Fetch returns
+// ErrNotFound on a miss.
+func (c *Cache[K, V]) Fetch(ctx context.Context, key K) (V, error) {
+	if c.loader == nil {
+		if v, ok := c.get(key); ok {
+			return v, nil
+		}
+		var zero V
+		return zero, ErrNotFound
+	}
+	return c.getOrLoad(ctx, key, c.readThroughLoader(key))
+}
+
+// readThroughLoader adapts the WithLoader loader to getOrLoad for key.
+func (c *Cache[K, V]) readThroughLoader(key K) func(context.Context) (V, time.Duration, error) {
+	return func(context.Context) (V, time.Duration, error) {
+		v, ttl, err := c.loader(key)
+		if ttl <= 0 {
+			ttl = c.defaultTTL
+		}
+		if ttl <= 0 {
+			ttl = storeForever
+		}
+		return v, ttl, err
+	}
+}
+
+// storeForever is the TTL a loader passed to getOrLoad returns for values
+// that never expire.
+const storeForever time.Duration = math.MinInt64
+
+// getOrLoad implements GetOrLoadContext and Fetch. loader returns the value to
+// store with its TTL, or storeForever.
+func (c *Cache[K, V]) getOrLoad(ctx context.Context, key K, loader func(ctx context.Context) (V, time.Duration, error)) (V, error) {
+	var zero V
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+	if v, ok := c.get(key); ok {
+		if c.refreshAhead > 0 {
+			c.refreshIfDue(key, func() (V, time.Duration, error) {
+				return loader(context.WithoutCancel(ctx))
+			})
+		}
+		return v, nil
+	}
+	return c.load(ctx, key, loader)
+}
+
+// load is getOrLoad after the lookup of key missed.
+func (c *Cache[K, V]) load(ctx context.Context, key K, loader func(ctx context.Context) (V, time.Duration, error)) (V, error) {
+	var zero V
+	if c.closed.Load() {
+		return zero, ErrClosed
+	}
+	c.loadMutex.Lock()
+	if call, ok := c.loads[key]; ok {
+		c.loadMutex.Unlock()
+		select {
+		case <-call.done:
+			return c.copyValue(call.value), call.err
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+	}
+	// A load that finished after our Get may already have stored the value.
+	if v, ok := c.Peek(key); ok {
+		c.loadMutex.Unlock()
+		return v, nil
+	}
+	if c.negativeTTL > 0 && c.cachedMiss(key) {
+		c.loadMutex.Unlock()
+		return zero, ErrNotFound
+	}
+	call := c.registerLoadLocked(key)
+	c.loadMutex.Unlock()
+
+	load := c.loadAndStore(ctx, key, func() (V, time.Duration, error) {
+		return loader(ctx)
+	})
+	c.runLoad(key, call, func() (V, error) {
+		v, err := load()
+		if c.negativeTTL > 0 && errors.Is(err, ErrNotFound) {
+			c.storeMiss(key)
+		}
+		return v, err
+	})
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+	return call.value, call.err
+}
+
+// cachedMiss reports whether a live negative entry is stored for key.
+func (c *Cache[K, V]) cachedMiss(key K) bool {
+	s := c.shardFor(key)
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	item, exists := s.data[key]
+	return exists && item.negative && !item.expired(c.clock.Now())
+}
+
+// storeMiss caches the absence of key for the negative TTL.
+func (c *Cache[K, V]) storeMiss(key K) {
+	if c.closed.Load() {
+		return
+	}
+	item := cacheItem[V]{
+		expiryTime: c.expiryAt(c.clock.Now(), c.negativeTTL),
+		ttl:        c.negativeTTL,
+		negative:   true,
+	}
+
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	evicted := c.storeLocked(s, key, item)
+	s.mutex.Unlock()
+
+	c.notifyEvicted(evicted)
+}
+
+// refreshIfDue reloads key in the background if its remaining TTL is within the
+// refresh-ahead window and no load for it is already in flight. The current
+// value stays in place until the reload succeeds, or until it expires if the
+// reload fails.
+func (c *Cache[K, V]) refreshIfDue(key K, loader func() (V, time.Duration, error)) {
+	remaining, ok := c.TTL(key)
+	if !ok || remaining == NoExpiration || remaining >= c.refreshAhead {
+		return
+	}
+
+	c.loadMutex.Lock()
+	if _, ok := c.loads[key]; ok {
+		c.loadMutex.Unlock()
+		return
+	}
+	call := c.registerLoadLocked(key)
+	c.loadMutex.Unlock()
+
+	go c.runLoad(key, call, c.loadAndStore(context.Background(), key, loader))
+}
+
+// registerLoadLocked records a new in-flight load for key. The caller must hold
+// loadMutex.
+func (c *Cache[K, V]) registerLoadLocked(key K) *loadCall[V] {
+	call := &loadCall[V]{done: make(chan struct{})}
+	if c.loads == nil {
+		c.loads = make(map[K]*loadCall[V])
+	}
+	c.loads[key] = call
+	return call
+}
+
+// loadAndStore wraps loader to store a successful result under key with the
+// TTL it returns, and to wrap its errors other than ErrNotFound in
+// ErrLoaderFailed. With WithLoaderRetry, a failed call is retried before its
+// error is returned.
+// With WithCircuitBreaker, the wrapper fails with ErrCircuitOpen while the
+// circuit is open. With WithMaxConcurrentLoads, it then waits for a free load
+// slot, returning ctx's error if ctx is done before one frees up.
+func (c *Cache[K, V]) loadAndStore(ctx context.Context, key K, loader func() (V, time.Duration, error)) func() (V, error) {
+	return func() (V, error) {
+		probe, done, err := c.startLoad(ctx)
+		if err != nil {
+			var zero V
+			return zero, err
+		}
+		defer done()
+
+		v, ttl, err := c.callLoader(probe, c.retrying(ctx, loader))
+		switch {
+		case errors.Is(err, ErrNotFound), err == errLoaderPanicked:
+		case err != nil:
+			err = fmt.Errorf("%w: %w", ErrLoaderFailed, err)
+		case ttl == storeForever:
+			c.SetForever(key, v)
+		default:
+			c.SetWithTTL(key, v, ttl)
+		}
+		return v, err
+	}
+}
+
+// startLoad admits a loader call past the circuit breaker and the limit on
+// concurrent loads, if configured, returning whether the call is the breaker's
+// probe. On success, the caller must call done once the loader has returned.
+func (c *Cache[K, V]) startLoad(ctx context.Context) (probe bool, done func(), err error) {
+	if c.breaker != nil {
+		if probe, err = c.breaker.allow(c.clock.Now()); err != nil {
+			return false, nil, err
+		}
+	}
+	if c.loadSlots != nil {
+		select {
+		case c.loadSlots <- struct{}{}:
+		case <-ctx.Done():
+			c.breaker.abandon(probe)
+			return false, nil, ctx.Err()
+		}
+	}
+	c.stats.inFlightLoads.Add(1)
+	return probe, func() {
+		c.stats.inFlightLoads.Add(-1)
+		if c.loadSlots != nil {
+			<-c.loadSlots
+		}
+	}, nil
+}
+
+// retrying wraps loader to retry failed calls as configured by
+// WithLoaderRetry, waiting between attempts with exponential backoff until ctx
+// is done. It returns loader itself if retries are disabled.
+func (c *Cache[K, V]) retrying(ctx context.Context, loader func() (V, time.Duration, error)) func() (V, time.Duration, error) {
+	if c.retryAttempts <= 1 {
+		return loader
+	}
+	return func() (V, time.Duration, error) {
+		delay := c.retryBaseDelay
+		for attempt := 1; ; attempt++ {
+			v, ttl, err := loader()
+			if err == nil || errors.Is(err, ErrNotFound) || attempt == c.retryAttempts {
+				return v, ttl, err
+			}
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+				return v, ttl, err
+			}
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return v, ttl, err
+			}
+			if delay < math.MaxInt64/2 {
+				delay *= 2
+			}
+		}
+	}
+}
+
+// callLoader calls loader, reporting its outcome to the circuit breaker, if
+// any, even if loader panics.
+func (c *Cache[K, V]) callLoader(probe bool, loader func() (V, time.Duration, error)) (v V, ttl time.Duration, err error) {
+	if c.panicHandler != nil {
+		loader = c.guardLoader(loader)
+	}
+	if c.breaker == nil {
+		return loader()
+	}
+	completed := false
+	defer func() {
+		if !completed {
+			c.breaker.record(c.clock.Now(), probe, errLoaderPanicked)
+		}
+	}()
+	v, ttl, err = loader()
+	completed = true
+	c.breaker.record(c.clock.Now(), probe, err)
+	return v, ttl, err
+}
+
+// guardLoader wraps loader to fail with errLoaderPanicked, rather than panic,
+// if loader panics, passing the panic to the WithPanicHandler handler.
+func (c *Cache[K, V]) guardLoader(loader func() (V, time.Duration, error)) func() (V, time.Duration, error) {
+	return func() (v V, ttl time.Duration, err error) {
+		err = errLoaderPanicked
+		c.guard(func() { v, ttl, err = loader() })
+		return v, ttl, err
+	}
+}
+
+// runLoad runs load for call and releases its waiters, removing the call from
+// the in-flight map even if load panics.
+func (c *Cache[K, V]) runLoad(key K, call *loadCall[V], load func() (V, error)) {
+	completed := false
+	defer func() {
+		if !completed {
+			call.err = errLoaderPanicked
+		}
+		c.loadMutex.Lock()
+		delete(c.loads, key)
+		c.loadMutex.Unlock()
+		close(call.done)
+	}()
+
+	call.value, call.err = load()
+	completed = true
+}
@@ -0,0 +1,809 @@
+package keyvalstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSimpleCache_GetOrLoad(t *testing.T) {
+	sut := NewSimpleCache[string](1 * time.Minute)
+
+	calls := 0
+	loader := func() (string, error) {
+		calls++
+		return "loaded", nil
+	}
+
+	for i := 0; i < 2; i++ {
+		val, err := sut.GetOrLoad("key1", time.Minute, loader)
+		if err != nil || val != "loaded" {
+			t.Errorf("Expected 'loaded' and no error, got '%s', err: %v", val, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected the loader to run once, ran %d times", calls)
+	}
+}
+
+func TestSimpleCache_GetOrLoadError(t *testing.T) {
+	sut := NewSimpleCache[string](1 * time.Minute)
+	errBackend := errors.New("backend down")
+
+	_, err := sut.GetOrLoad("key1", time.Minute, func() (string, error) {
+		return "partial", errBackend
+	})
+	if !errors.Is(err, errBackend) {
+		t.Errorf("Expected the loader error to be returned, got %v", err)
+	}
+	if !errors.Is(err, ErrLoaderFailed) {
+		t.Errorf("Expected the loader error to be wrapped in ErrLoaderFailed, got %v", err)
+	}
+	if sut.Has("key1") {
+		t.Errorf("Expected a failed load not to be cached")
+	}
+	if len(sut.loads) != 0 {
+		t.Errorf("Expected no in-flight loads after completion, got %d", len(sut.loads))
+	}
+}
+
+func TestSimpleCache_GetOrLoadErrorKinds(t *testing.T) {
+	sut := NewSimpleCache(0, WithCircuitBreaker[string](1, time.Minute, time.Minute))
+
+	_, err := sut.GetOrLoad("missing", time.Minute, func() (string, error) { return "", ErrNotFound })
+	if !errors.Is(err, ErrNotFound) || errors.Is(err, ErrLoaderFailed) {
+		t.Errorf("Expected ErrNotFound not to count as a loader failure, got %v", err)
+	}
+
+	func() {
+		defer func() { recover() }()
+		sut.GetOrLoad("key1", time.Minute, func() (string, error) { panic("boom") })
+	}()
+	_, err = sut.GetOrLoad("key2", time.Minute, func() (string, error) { return "value", nil })
+	if !errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrLoaderFailed) {
+		t.Errorf("Expected ErrCircuitOpen after a panicking load tripped the breaker, got %v", err)
+	}
+}
+
+func TestSimpleCache_GetOrLoadDeduplicatesConcurrentCalls(t *testing.T) {
+	sut := NewSimpleCache[int](1 * time.Minute)
+	const numGoroutines = 50
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	loader := func() (int, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(numGoroutines)
+	for i := 0; i < numGoroutines; i++ {
+		go func() {
+			defer wg.Done()
+			if v, err := sut.GetOrLoad("key", time.Minute, loader); err != nil || v != 42 {
+				t.Errorf("Expected 42 and no error, got %d, err: %v", v, err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("Expected the loader to run once, ran %d times", n)
+	}
+	if len(sut.loads) != 0 {
+		t.Errorf("Expected no in-flight loads after completion, got %d", len(sut.loads))
+	}
+}
+
+// waitForLoads waits until c has no loads in flight.
+func waitForLoads[T any](t *testing.T, c *SimpleCache[T]) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		c.loadMutex.Lock()
+		n := len(c.loads)
+		c.loadMutex.Unlock()
+		if n == 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Expected in-flight loads to finish")
+}
+
+func TestSimpleCache_GetOrLoadRefreshAhead(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithClock[string](clock), WithRefreshAhead[string](5*time.Second))
+	defer sut.Close()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	loader := func() (string, error) {
+		if calls.Add(1) == 1 {
+			return "v1", nil
+		}
+		<-release
+		return "v2", nil
+	}
+
+	if val, _ := sut.GetOrLoad("key1", 10*time.Second, loader); val != "v1" {
+		t.Fatalf("Expected the initial load to return 'v1', got '%s'", val)
+	}
+	clock.Advance(3 * time.Second)
+	if val, _ := sut.GetOrLoad("key1", 10*time.Second, loader); val != "v1" || calls.Load() != 1 {
+		t.Errorf("Expected no refresh outside the window, got '%s' after %d calls", val, calls.Load())
+	}
+
+	clock.Advance(3 * time.Second)
+	for i := 0; i < 5; i++ {
+		if val, err := sut.GetOrLoad("key1", 10*time.Second, loader); err != nil || val != "v1" {
+			t.Errorf("Expected the stale value 'v1' while refreshing, got '%s', err: %v", val, err)
+		}
+	}
+	close(release)
+	waitForLoads(t, sut)
+
+	if n := calls.Load(); n != 2 {
+		t.Errorf("Expected a single background refresh, got %d loader calls", n)
+	}
+	if val, _ := sut.Get("key1"); val != "v2" {
+		t.Errorf("Expected the refreshed value 'v2', got '%s'", val)
+	}
+	if ttl, _ := sut.TTL("key1"); ttl != 10*time.Second {
+		t.Errorf("Expected the refresh to store a full TTL, got %v", ttl)
+	}
+}
+
+func TestSimpleCache_GetOrLoadRefreshAheadErrorKeepsValue(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithClock[string](clock), WithRefreshAhead[string](5*time.Second))
+	defer sut.Close()
+
+	sut.SetWithTTL("key1", "v1", 10*time.Second)
+	clock.Advance(6 * time.Second)
+	val, err := sut.GetOrLoad("key1", 10*time.Second, func() (string, error) {
+		return "", errors.New("backend down")
+	})
+	if err != nil || val != "v1" {
+		t.Errorf("Expected the current value 'v1' and no error, got '%s', err: %v", val, err)
+	}
+	waitForLoads(t, sut)
+
+	if val, found := sut.Get("key1"); !found || val != "v1" {
+		t.Errorf("Expected a failed refresh to keep 'v1', got '%s', found: %v", val, found)
+	}
+	if ttl, _ := sut.TTL("key1"); ttl != 4*time.Second {
+		t.Errorf("Expected a failed refresh to keep the old expiry, got TTL %v", ttl)
+	}
+}
+
+func TestSimpleCache_GetOrLoadNegativeCaching(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithClock[string](clock), WithNegativeCaching[string](time.Second))
+	defer sut.Close()
+	var evicted []string
+	sut.OnEvicted(func(key string, value string, reason EvictionReason) {
+		evicted = append(evicted, key)
+	})
+
+	calls := 0
+	loader := func() (string, error) {
+		calls++
+		return "", fmt.Errorf("user 42: %w", ErrNotFound)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := sut.GetOrLoad("key1", time.Minute, loader); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected the cached miss to suppress further loads, loader ran %d times", calls)
+	}
+	if val, found := sut.Get("key1"); found || val != "" {
+		t.Errorf("Expected Get to report a cached miss as absent, got '%s', found: %v", val, found)
+	}
+	if sut.Has("key1") || sut.Len() != 0 || len(sut.Keys()) != 0 {
+		t.Errorf("Expected a cached miss to be invisible to reads")
+	}
+
+	clock.Advance(2 * time.Second)
+	if _, err := sut.GetOrLoad("key1", time.Minute, loader); !errors.Is(err, ErrNotFound) || calls != 2 {
+		t.Errorf("Expected the loader to run again once the miss expired, got err %v after %d calls", err, calls)
+	}
+
+	sut.Set("key1", "value1")
+	if val, err := sut.GetOrLoad("key1", time.Minute, loader); err != nil || val != "value1" {
+		t.Errorf("Expected Set to replace the cached miss, got '%s', err: %v", val, err)
+	}
+	if len(evicted) != 0 {
+		t.Errorf("Expected cached misses never to reach the eviction callback, got %v", evicted)
+	}
+}
+
+func TestSimpleCache_GetOrLoadNotFoundWithoutNegativeCaching(t *testing.T) {
+	sut := NewSimpleCache[string](0)
+	defer sut.Close()
+
+	calls := 0
+	for i := 0; i < 2; i++ {
+		sut.GetOrLoad("key1", time.Minute, func() (string, error) {
+			calls++
+			return "", ErrNotFound
+		})
+	}
+	if calls != 2 {
+		t.Errorf("Expected misses not to be cached by default, loader ran %d times", calls)
+	}
+}
+
+type ctxKey struct{}
+
+func TestSimpleCache_GetOrLoadContext(t *testing.T) {
+	sut := NewSimpleCache[string](0)
+	defer sut.Close()
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "from context")
+	val, err := sut.GetOrLoadContext(ctx, "key1", time.Minute, func(ctx context.Context) (string, error) {
+		return ctx.Value(ctxKey{}).(string), nil
+	})
+	if err != nil || val != "from context" {
+		t.Errorf("Expected the loader to receive ctx, got '%s', err: %v", val, err)
+	}
+	if val, found := sut.Get("key1"); !found || val != "from context" {
+		t.Errorf("Expected the loaded value to be cached, got '%s', found: %v", val, found)
+	}
+}
+
+func TestSimpleCache_GetOrLoadContextCancelled(t *testing.T) {
+	sut := NewSimpleCache[string](0)
+	defer sut.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	_, err := sut.GetOrLoadContext(ctx, "key1", time.Minute, func(context.Context) (string, error) {
+		called = true
+		return "loaded", nil
+	})
+	if !errors.Is(err, context.Canceled) || called {
+		t.Errorf("Expected context.Canceled without calling the loader, got %v, called: %v", err, called)
+	}
+}
+
+func TestSimpleCache_GetOrLoadContextCancelledMidLoad(t *testing.T) {
+	sut := NewSimpleCache[string](0)
+	defer sut.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	_, err := sut.GetOrLoadContext(ctx, "key1", time.Minute, func(ctx context.Context) (string, error) {
+		cancel()
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if sut.Has("key1") || len(sut.loads) != 0 {
+		t.Errorf("Expected nothing cached and no in-flight loads after cancellation")
+	}
+}
+
+func TestSimpleCache_GetOrLoadContextAbandonsWait(t *testing.T) {
+	sut := NewSimpleCache[string](0)
+	defer sut.Close()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	leader := make(chan string)
+	go func() {
+		val, _ := sut.GetOrLoad("key1", time.Minute, func() (string, error) {
+			close(started)
+			<-release
+			return "loaded", nil
+		})
+		leader <- val
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := sut.GetOrLoadContext(ctx, "key1", time.Minute, func(context.Context) (string, error) {
+		t.Errorf("Expected the waiter not to start its own load")
+		return "", nil
+	}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the waiter to give up with context.DeadlineExceeded, got %v", err)
+	}
+
+	close(release)
+	if val := <-leader; val != "loaded" {
+		t.Errorf("Expected the abandoned load to complete for its leader, got '%s'", val)
+	}
+}
+
+func TestSimpleCache_WithMaxConcurrentLoads(t *testing.T) {
+	sut := NewSimpleCache(0, WithMaxConcurrentLoads[string](2))
+
+	var running, peak atomic.Int32
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sut.GetOrLoad(fmt.Sprintf("key%d", i), time.Minute, func() (string, error) {
+				n := running.Add(1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				<-release
+				running.Add(-1)
+				return "value", nil
+			})
+		}()
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for sut.Stats().InFlightLoads < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if n := sut.Stats().InFlightLoads; n != 2 {
+		t.Errorf("Expected 2 loads in flight at the limit, got %d", n)
+	}
+	close(release)
+	wg.Wait()
+
+	if p := peak.Load(); p != 2 {
+		t.Errorf("Expected at most 2 concurrent loads, got a peak of %d", p)
+	}
+	if n := sut.Len(); n != 6 {
+		t.Errorf("Expected every queued load to run eventually, got %d entries", n)
+	}
+	if n := sut.Stats().InFlightLoads; n != 0 {
+		t.Errorf("Expected no loads in flight afterwards, got %d", n)
+	}
+}
+
+func TestSimpleCache_WithMaxConcurrentLoadsRespectsContext(t *testing.T) {
+	sut := NewSimpleCache(0, WithMaxConcurrentLoads[string](1))
+
+	release := make(chan struct{})
+	go sut.GetOrLoad("busy", time.Minute, func() (string, error) {
+		<-release
+		return "value", nil
+	})
+	defer close(release)
+	deadline := time.Now().Add(time.Second)
+	for sut.Stats().InFlightLoads < 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	called := false
+	_, err := sut.GetOrLoadContext(ctx, "queued", time.Minute, func(context.Context) (string, error) {
+		called = true
+		return "value", nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a queued load to give up at its deadline, got %v", err)
+	}
+	if called {
+		t.Errorf("Expected the queued loader not to run")
+	}
+}
+
+func TestSimpleCache_WithLoader(t *testing.T) {
+	var calls atomic.Int32
+	sut := NewSimpleCache(0, WithLoader(func(key string) (string, time.Duration, error) {
+		calls.Add(1)
+		return "loaded " + key, time.Minute, nil
+	}))
+
+	if _, ok := sut.Peek("key1"); ok {
+		t.Errorf("Expected Peek not to load")
+	}
+	for i := 0; i < 2; i++ {
+		val, ok := sut.Get("key1")
+		if !ok || val != "loaded key1" {
+			t.Errorf("Expected 'loaded key1', got '%s' (found: %v)", val, ok)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("Expected the loader to run once, ran %d times", n)
+	}
+	if ttl, ok := sut.TTL("key1"); !ok || ttl <= 0 || ttl > time.Minute {
+		t.Errorf("Expected the loaded value to be stored with the loader's TTL, got %v", ttl)
+	}
+}
+
+func TestSimpleCache_WithLoaderRefreshAhead(t *testing.T) {
+	clock := newFakeClock()
+	var calls atomic.Int32
+	sut := NewSimpleCache(0,
+		WithClock[string](clock),
+		WithRefreshAhead[string](10*time.Second),
+		WithLoader(func(key string) (string, time.Duration, error) {
+			return fmt.Sprintf("%s v%d", key, calls.Add(1)), time.Minute, nil
+		}),
+	)
+
+	sut.Get("key1")
+	clock.Advance(55 * time.Second)
+	if val, ok := sut.Get("key1"); !ok || val != "key1 v1" {
+		t.Errorf("Expected the current value while it refreshes, got '%s' (found: %v)", val, ok)
+	}
+	waitForLoads(t, sut)
+	if val, _ := sut.Peek("key1"); val != "key1 v2" {
+		t.Errorf("Expected Get to refresh a value close to expiry, got '%s'", val)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("Expected the loader to run twice, ran %d times", n)
+	}
+}
+
+func TestSimpleCache_WithLoaderDefaultTTL(t *testing.T) {
+	sut := NewSimpleCache(0,
+		WithDefaultTTL[int](time.Hour),
+		WithLoader(func(key string) (int, time.Duration, error) { return len(key), 0, nil }),
+	)
+
+	if val, ok := sut.Get("key1"); !ok || val != 4 {
+		t.Errorf("Expected 4, got %d (found: %v)", val, ok)
+	}
+	if ttl, ok := sut.TTL("key1"); !ok || ttl <= time.Minute || ttl > time.Hour {
+		t.Errorf("Expected a zero TTL to fall back to the default TTL, got %v", ttl)
+	}
+}
+
+func TestSimpleCache_WithLoaderDeduplicates(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	sut := NewSimpleCache(0, WithLoader(func(key string) (string, time.Duration, error) {
+		calls.Add(1)
+		<-release
+		return "value", 0, nil
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if val, ok := sut.Get("key1"); !ok || val != "value" {
+				t.Errorf("Expected 'value', got '%s' (found: %v)", val, ok)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("Expected concurrent misses to share one load, ran %d times", n)
+	}
+}
+
+func TestSimpleCache_FetchError(t *testing.T) {
+	errBackend := errors.New("backend down")
+	sut := NewSimpleCache(0, WithLoader(func(key string) (string, time.Duration, error) {
+		return "", 0, errBackend
+	}))
+
+	if _, err := sut.Fetch(context.Background(), "key1"); !errors.Is(err, errBackend) {
+		t.Errorf("Expected the loader's error, got %v", err)
+	}
+	if _, ok := sut.Get("key1"); ok {
+		t.Errorf("Expected a failed load to be reported as a miss")
+	}
+	if sut.Len() != 0 {
+		t.Errorf("Expected nothing to be cached after a failed load")
+	}
+}
+
+func TestSimpleCache_FetchWithoutLoader(t *testing.T) {
+	sut := NewSimpleCache[string](0)
+	sut.Set("key1", "value1")
+
+	if val, err := sut.Fetch(context.Background(), "key1"); err != nil || val != "value1" {
+		t.Errorf("Expected 'value1' and no error, got '%s', err: %v", val, err)
+	}
+	if _, err := sut.Fetch(context.Background(), "key2"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound without a loader, got %v", err)
+	}
+}
+
+func TestNewCache_WithLoaderKeyMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a loader for another key type to panic")
+		}
+	}()
+	NewCache[int](WithLoader(func(key string) (string, time.Duration, error) { return key, 0, nil }))
+}
+
+func TestSimpleCache_WithLoaderRetry(t *testing.T) {
+	sut := NewSimpleCache(0, WithLoaderRetry[string](3, time.Millisecond))
+	errFlaky := errors.New("flaky")
+
+	calls := 0
+	val, err := sut.GetOrLoad("key1", time.Minute, func() (string, error) {
+		calls++
+		if calls < 3 {
+			return "", errFlaky
+		}
+		return "loaded", nil
+	})
+	if err != nil || val != "loaded" || calls != 3 {
+		t.Errorf("Expected 'loaded' on the third attempt, got '%s', err: %v after %d calls", val, err, calls)
+	}
+	if !sut.Has("key1") {
+		t.Errorf("Expected the value loaded on retry to be cached")
+	}
+
+	calls = 0
+	start := time.Now()
+	_, err = sut.GetOrLoad("key2", time.Minute, func() (string, error) {
+		calls++
+		return "", fmt.Errorf("attempt %d: %w", calls, errFlaky)
+	})
+	if !errors.Is(err, ErrLoaderFailed) || !errors.Is(err, errFlaky) || calls != 3 {
+		t.Errorf("Expected the last error wrapped in ErrLoaderFailed after 3 calls, got %v after %d calls", err, calls)
+	}
+	if err != nil && !strings.Contains(err.Error(), "attempt 3") {
+		t.Errorf("Expected the last attempt's error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 3*time.Millisecond {
+		t.Errorf("Expected backoff of 1ms then 2ms between attempts, took %v", elapsed)
+	}
+
+	calls = 0
+	sut.GetOrLoad("key3", time.Minute, func() (string, error) {
+		calls++
+		return "", ErrNotFound
+	})
+	if calls != 1 {
+		t.Errorf("Expected ErrNotFound not to be retried, got %d calls", calls)
+	}
+}
+
+func TestSimpleCache_WithLoaderRetryRespectsDeadline(t *testing.T) {
+	sut := NewSimpleCache(0, WithLoaderRetry[string](5, time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	calls := 0
+	start := time.Now()
+	_, err := sut.GetOrLoadContext(ctx, "key1", time.Minute, func(context.Context) (string, error) {
+		calls++
+		return "", errors.New("down")
+	})
+	if !errors.Is(err, ErrLoaderFailed) || calls != 1 {
+		t.Errorf("Expected to give up when the backoff outlasts the deadline, got %v after %d calls", err, calls)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected not to wait for a retry past the deadline, took %v", elapsed)
+	}
+}
+
+func TestSimpleCache_GetOrLoadMany(t *testing.T) {
+	sut := NewSimpleCache(0, WithClock[string](newFakeClock()), WithNegativeCaching[string](time.Minute))
+	sut.Set("key1", "cached")
+
+	var requested [][]string
+	loader := func(missing []string) (map[string]string, error) {
+		requested = append(requested, missing)
+		return map[string]string{"key2": "loaded2", "key3": "loaded3", "other": "ignored"}, nil
+	}
+
+	got, err := sut.GetOrLoadMany([]string{"key1", "key2", "key3", "key4", "key2"}, time.Minute, loader)
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	want := map[string]string{"key1": "cached", "key2": "loaded2", "key3": "loaded3"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if fmt.Sprint(requested) != "[[key2 key3 key4]]" {
+		t.Errorf("Expected the loader to receive only the missing keys once, got %v", requested)
+	}
+	if ttl, _ := sut.TTL("key2"); ttl != time.Minute {
+		t.Errorf("Expected loaded values to be stored with the given TTL, got %v", ttl)
+	}
+	if sut.Has("other") {
+		t.Errorf("Expected values for keys that were not asked for to be ignored")
+	}
+
+	requested = nil
+	got, err = sut.GetOrLoadMany([]string{"key2", "key4"}, time.Minute, loader)
+	if err != nil || len(got) != 1 || requested != nil {
+		t.Errorf("Expected the stored value and the cached miss to need no load, got %v, err: %v, loaded: %v", got, err, requested)
+	}
+}
+
+func TestSimpleCache_GetOrLoadManyError(t *testing.T) {
+	sut := NewSimpleCache[string](0)
+	sut.Set("key1", "cached")
+	errBackend := errors.New("backend down")
+
+	got, err := sut.GetOrLoadMany([]string{"key1", "key2"}, time.Minute, func([]string) (map[string]string, error) {
+		return map[string]string{"key2": "partial"}, errBackend
+	})
+	if !errors.Is(err, errBackend) || !errors.Is(err, ErrLoaderFailed) {
+		t.Errorf("Expected the loader error wrapped in ErrLoaderFailed, got %v", err)
+	}
+	if len(got) != 1 || got["key1"] != "cached" {
+		t.Errorf("Expected only the cached value, got %v", got)
+	}
+	if sut.Has("key2") {
+		t.Errorf("Expected a failed load not to be cached")
+	}
+
+	func() {
+		defer func() { recover() }()
+		sut.GetOrLoadMany([]string{"key3"}, time.Minute, func([]string) (map[string]string, error) { panic("boom") })
+	}()
+	if len(sut.loads) != 0 {
+		t.Errorf("Expected no in-flight loads after a panicking load, got %d", len(sut.loads))
+	}
+}
+
+func TestSimpleCache_GetOrLoadManyDeduplicatesOverlappingCalls(t *testing.T) {
+	sut := NewSimpleCache[int](0)
+
+	var mu sync.Mutex
+	loads := make(map[string]int)
+	release := make(chan struct{})
+	loader := func(missing []string) (map[string]int, error) {
+		<-release
+		mu.Lock()
+		defer mu.Unlock()
+		values := make(map[string]int, len(missing))
+		for _, key := range missing {
+			loads[key]++
+			values[key] = len(key)
+		}
+		return values, nil
+	}
+
+	batches := [][]string{{"a", "bb"}, {"bb", "ccc"}, {"a", "ccc", "dddd"}}
+	var wg sync.WaitGroup
+	for _, keys := range batches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := sut.GetOrLoadMany(keys, time.Minute, loader)
+			if err != nil || len(got) != len(keys) {
+				t.Errorf("Expected a value for each of %v, got %v, err: %v", keys, got, err)
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if v, err := sut.GetOrLoad("bb", time.Minute, func() (int, error) { return 2, nil }); err != nil || v != 2 {
+			t.Errorf("Expected 2 and no error, got %d, err: %v", v, err)
+		}
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for key, n := range loads {
+		if n != 1 {
+			t.Errorf("Expected %s to be loaded once, loaded %d times", key, n)
+		}
+	}
+	if len(sut.loads) != 0 {
+		t.Errorf("Expected no in-flight loads after completion, got %d", len(sut.loads))
+	}
+}
+
+func TestSimpleCache_WithPanicHandlerRefreshCopier(t *testing.T) {
+	clock := newFakeClock()
+	recovered := make(chan any, 1)
+	sut := NewSimpleCache(0,
+		WithClock[string](clock),
+		WithRefreshAhead[string](5*time.Second),
+		WithPanicHandler[string](func(r any) { recovered <- r }),
+		WithCopier[string](func(v string) string {
+			if v == "v2" {
+				panic("copy")
+			}
+			return v
+		}),
+	)
+	defer sut.Close()
+
+	var calls atomic.Int32
+	loader := func() (string, error) {
+		if calls.Add(1) == 1 {
+			return "v1", nil
+		}
+		return "v2", nil
+	}
+	sut.GetOrLoad("key1", 10*time.Second, loader)
+	clock.Advance(6 * time.Second)
+	sut.GetOrLoad("key1", 10*time.Second, loader)
+	waitForLoads(t, sut)
+
+	if r := <-recovered; r != "copy" {
+		t.Errorf("Expected the refresh's copier panic to be recovered, got %v", r)
+	}
+	if val, _ := sut.Get("key1"); val != "v1" {
+		t.Errorf("Expected the rejected refresh to keep 'v1', got '%s'", val)
+	}
+}
+
+func TestSimpleCache_WithPanicHandlerEstimators(t *testing.T) {
+	var recovered []any
+	sut := NewSimpleCache(0,
+		WithPanicHandler[string](func(r any) { recovered = append(recovered, r) }),
+		WithMaxCost[string](10, func(v string) int64 {
+			if v == "bad" {
+				panic("cost")
+			}
+			return 1
+		}),
+	)
+
+	if err := sut.Store("key1", "bad", time.Minute); err == nil {
+		t.Errorf("Expected Store to fail when the cost function panics")
+	}
+	if sut.SetWithTTL("key2", "bad", time.Minute) {
+		t.Errorf("Expected Set to reject a value whose cost function panics")
+	}
+	if _, found := sut.Get("key1"); found {
+		t.Errorf("Expected the rejected value not to be stored")
+	}
+	if fmt.Sprint(recovered) != "[cost cost]" {
+		t.Errorf("Expected the cost function panics to be recovered, got %v", recovered)
+	}
+}
+
+func TestSimpleCache_WithPanicHandlerLoader(t *testing.T) {
+	var recovered []any
+	sut := NewSimpleCache(0,
+		WithPanicHandler[string](func(r any) { recovered = append(recovered, r) }),
+		WithLoaderRetry[string](3, time.Millisecond),
+		WithOnMiss[string](func(string) { panic("miss") }),
+	)
+
+	calls := 0
+	_, err := sut.GetOrLoad("key1", time.Minute, func() (string, error) {
+		calls++
+		panic("boom")
+	})
+	if err != errLoaderPanicked || !errors.Is(err, ErrLoaderFailed) {
+		t.Errorf("Expected a panicking loader to fail with ErrLoaderFailed, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected a panicking loader not to be retried, ran %d times", calls)
+	}
+
+	got, err := sut.GetOrLoadMany([]string{"key2"}, time.Minute, func([]string) (map[string]string, error) {
+		panic("batch")
+	})
+	if len(got) != 0 || err != errLoaderPanicked {
+		t.Errorf("Expected a panicking batch loader to fail with ErrLoaderFailed, got %v, err: %v", got, err)
+	}
+	if fmt.Sprint(recovered) != "[miss boom miss batch]" {
+		t.Errorf("Expected the hook and loader panics to be recovered, got %v", recovered)
+	}
+	if len(sut.loads) != 0 {
+		t.Errorf("Expected no in-flight loads after the panics, got %d", len(sut.loads))
+	}
+}
@@ -0,0 +1,35 @@
+package keyvalstore
+
+import "path"
+
+// Match returns the live entries whose keys match pattern, using the syntax of
+// path.Match, e.g. "session:*:active". As in path.Match, '*' does not match
+// '/', so choose a different separator for hierarchical keys that '*' should
+// cross. It returns path.ErrBadPattern if pattern is malformed. Reads have no
+// side effects, as with Peek.
+//
+// Match tests every key in the cache against pattern: it is O(n) in the size of
+// the cache and holds every shard's read lock while scanning, so it is meant for
+// administrative and debugging tools rather than for the hot path. It is a
+// function rather than a method because it requires string keys.
+func Match[K ~string, V any](c *Cache[K, V], pattern string) (map[K]V, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	c.rlockAll()
+	defer c.runlockAll()
+	now := c.clock.Now()
+	matches := make(map[K]V)
+	for _, s := range c.shards {
+		for key, it := range s.data {
+			if !it.live(now) {
+				continue
+			}
+			if ok, _ := path.Match(pattern, string(key)); ok {
+				matches[key] = c.copyValue(it.value)
+			}
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,41 @@
+package keyvalstore
+
+import (
+	"errors"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestMatch(t *testing.T) {
+	sut := NewSimpleCache(0, WithShards[string](4))
+	sut.Set("session:1:active", "alice")
+	sut.Set("session:2:active", "bob")
+	sut.Set("session:3:idle", "carol")
+	sut.SetWithTTL("session:4:active", "dave", -time.Second)
+	sut.Set("user:1", "alice")
+
+	got, err := Match(sut, "session:*:active")
+	if err != nil {
+		t.Fatalf("Expected a valid pattern, got %v", err)
+	}
+	if len(got) != 2 || got["session:1:active"] != "alice" || got["session:2:active"] != "bob" {
+		t.Errorf("Expected the 2 live active sessions, got %v", got)
+	}
+
+	if got, _ = Match(sut, "user:?"); len(got) != 1 {
+		t.Errorf("Expected ? to match a single character, got %v", got)
+	}
+	if got, _ = Match(sut, "nothing:*"); len(got) != 0 {
+		t.Errorf("Expected no matches, got %v", got)
+	}
+}
+
+func TestMatch_BadPattern(t *testing.T) {
+	sut := NewSimpleCache[string](0)
+	sut.Set("key1", "value1")
+
+	if _, err := Match(sut, "key["); !errors.Is(err, path.ErrBadPattern) {
+		t.Errorf("Expected path.ErrBadPattern for a malformed pattern, got %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package keyvalstore
+
+import (
+	"maps"
+	"time"
+)
+
+// SetWithMeta adds a key-value pair to the cache with an expiration time, as
+// SetWithTTL does, and attaches meta to it, e.g. the value's source or version.
+// The cache keeps its own copy of meta, so later changes to the map do not
+// affect the stored entry. Storing the key again replaces its metadata with
+// that of the new call; Set and SetWithTTL leave it with none.
+func (c *Cache[K, V]) SetWithMeta(key K, value V, ttl time.Duration, meta map[string]string) bool {
+	item, err := c.newItem(key, value, ttl, c.expiryAt(c.clock.Now(), ttl))
+	if err != nil {
+		return false
+	}
+	item.meta = maps.Clone(meta)
+
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	evicted := c.storeLocked(s, key, item)
+	s.mutex.Unlock()
+
+	c.notifyEvicted(evicted)
+	return true
+}
+
+// GetWithMeta returns the value for key, a copy of the metadata it was stored
+// with, and whether it was found and not expired. Entries stored without
+// metadata return a nil map. Like GetWithExpiry, it counts towards hits and
+// misses but does not slide the item's expiry or update LRU recency or LFU
+// frequency.
+func (c *Cache[K, V]) GetWithMeta(key K) (V, map[string]string, bool) {
+	s := c.shardFor(key)
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	item, exists := s.data[key]
+	if !exists || !item.live(c.clock.Now()) {
+		c.stats.misses.Add(1)
+		var zero V
+		return zero, nil, false
+	}
+	c.stats.hits.Add(1)
+	return c.copyValue(item.value), maps.Clone(item.meta), true
+}
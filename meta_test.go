@@ -0,0 +1,55 @@
+package keyvalstore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSimpleCache_SetWithMeta(t *testing.T) {
+	sut := NewSimpleCache[string](1 * time.Minute)
+	defer sut.Close()
+
+	meta := map[string]string{"source": "db", "version": "3"}
+	sut.SetWithMeta("key1", "value1", time.Minute, meta)
+	meta["source"] = "changed"
+
+	val, got, found := sut.GetWithMeta("key1")
+	if !found || val != "value1" || got["source"] != "db" || got["version"] != "3" {
+		t.Errorf("Expected value1 with its original metadata, got '%s' %v, found: %v", val, got, found)
+	}
+	got["version"] = "4"
+	if _, again, _ := sut.GetWithMeta("key1"); again["version"] != "3" {
+		t.Errorf("Expected GetWithMeta to return a copy, got %v", again)
+	}
+
+	sut.Set("key1", "value2")
+	if _, got, _ = sut.GetWithMeta("key1"); got != nil {
+		t.Errorf("Expected Set to clear the metadata, got %v", got)
+	}
+}
+
+func TestSimpleCache_GetWithMetaMissing(t *testing.T) {
+	sut := NewSimpleCache[string](1 * time.Minute)
+	defer sut.Close()
+
+	sut.SetWithMeta("expired", "value1", -time.Second, map[string]string{"source": "db"})
+	for _, key := range []string{"expired", "missing"} {
+		if val, meta, found := sut.GetWithMeta(key); found || val != "" || meta != nil {
+			t.Errorf("Expected %s to be absent, got '%s' %v, found: %v", key, val, meta, found)
+		}
+	}
+}
+
+func TestSimpleCache_MetaSurvivesUpdate(t *testing.T) {
+	sut := NewSimpleCache[int](1 * time.Minute)
+	defer sut.Close()
+
+	sut.SetWithMeta("counter", 1, time.Minute, map[string]string{"origin": "node-a"})
+	Increment(sut, "counter", 1, time.Minute)
+	sut.Update("counter", func(old int, _ bool) (int, bool) { return old * 10, true })
+
+	val, meta, _ := sut.GetWithMeta("counter")
+	if val != 20 || meta["origin"] != "node-a" {
+		t.Errorf("Expected Increment and Update to keep the metadata, got %d %v", val, meta)
+	}
+}
@@ -0,0 +1,44 @@
+package keyvalstore
+
+import "sync"
+
+// onceValue is the value computed by Once for a key.
+type onceValue[V any] struct {
+	mutex sync.Mutex
+	value V
+	done  bool
+}
+
+// Once returns the value compute produces for key, calling compute only the
+// first time Once is called for key. Concurrent first calls for the same key
+// wait for a single call to compute and all receive its result, and once
+// compute has returned, its result is returned for key for the lifetime of
+// the cache, without ever calling compute again. If compute panics, the panic
+// propagates to its caller, nothing is remembered, and the next call for key
+// calls compute again.
+//
+// Values computed by Once are kept apart from the entries stored with Set and
+// the other setters: Get does not return them, and they never expire and are
+// not subject to capacity limits, Delete or Clear. They are never forgotten
+// either, so Once suits a bounded set of keys, such as memoized configuration
+// or compiled templates, rather than keys taken from requests.
+func (c *Cache[K, V]) Once(key K, compute func() V) V {
+	c.onceMutex.Lock()
+	e, ok := c.onces[key]
+	if !ok {
+		e = &onceValue[V]{}
+		if c.onces == nil {
+			c.onces = make(map[K]*onceValue[V])
+		}
+		c.onces[key] = e
+	}
+	c.onceMutex.Unlock()
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if !e.done {
+		e.value = c.copyValue(compute())
+		e.done = true
+	}
+	return c.copyValue(e.value)
+}
@@ -0,0 +1,74 @@
+package keyvalstore
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSimpleCache_Once(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithClock[string](clock), WithDefaultTTL[string](time.Minute))
+
+	calls := 0
+	compute := func() string {
+		calls++
+		return "computed"
+	}
+	if val := sut.Once("key1", compute); val != "computed" {
+		t.Errorf("Expected 'computed', got '%s'", val)
+	}
+	clock.Advance(time.Hour)
+	sut.Clear()
+	if val := sut.Once("key1", compute); val != "computed" {
+		t.Errorf("Expected the remembered 'computed', got '%s'", val)
+	}
+	if calls != 1 {
+		t.Errorf("Expected compute to run once, ran %d times", calls)
+	}
+	if _, ok := sut.Get("key1"); ok {
+		t.Errorf("Expected values computed by Once to be kept apart from Get")
+	}
+}
+
+func TestSimpleCache_OnceConcurrent(t *testing.T) {
+	sut := NewSimpleCache[int](0)
+
+	var calls atomic.Int32
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			val := sut.Once("key1", func() int {
+				calls.Add(1)
+				time.Sleep(5 * time.Millisecond)
+				return 42
+			})
+			if val != 42 {
+				t.Errorf("Expected 42, got %d", val)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Errorf("Expected concurrent first calls to share one compute, ran %d times", n)
+	}
+}
+
+func TestSimpleCache_OncePanicRetries(t *testing.T) {
+	sut := NewSimpleCache[string](0)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Expected the panic to propagate")
+			}
+		}()
+		sut.Once("key1", func() string { panic("boom") })
+	}()
+	if val := sut.Once("key1", func() string { return "retried" }); val != "retried" {
+		t.Errorf("Expected a panicking compute to be retried, got '%s'", val)
+	}
+}
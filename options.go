@@ -0,0 +1,528 @@
+package keyvalstore
+
+import "time"
+
+// Option configures a cache at construction time; see NewCache. Options
+// depend only on the value type, so the same options configure caches with
+// any key type.
+type Option[V any] func(*config[V])
+
+// config holds the settings options can change.
+type config[V any] struct {
+	cleanupInterval time.Duration
+	// minCleanup and maxCleanup bound the janitor's interval when it adapts
+	// to how much each sweep removes, and are zero otherwise.
+	minCleanup time.Duration
+	maxCleanup time.Duration
+
+	shardCount      int
+	initialCapacity int
+	defaultTTL      time.Duration
+	sliding         bool
+	jitter          float64
+	clock           Clock
+	// cleanupOffset, if set, returns how long the janitor waits before its
+	// first sweep given its interval. WithRandomizedCleanup sets it.
+	cleanupOffset func(interval time.Duration) time.Duration
+
+	// promoteAfter and maxPromotedTTL configure WithSegmentedTTL, which is
+	// disabled while maxPromotedTTL is zero.
+	promoteAfter   int
+	maxPromotedTTL time.Duration
+
+	// maxEntries and maxCost bound the cache when positive, with policy
+	// selecting each shard's eviction policy. policy is noPolicy when the
+	// cache is unbounded.
+	maxEntries int
+	maxCost    int64
+	costFn     func(V) int64
+	policy     policyKind
+	// decayInterval is how often LFU access counts are halved, if positive.
+	decayInterval time.Duration
+
+	// maxBytes bounds the estimated memory held by the cache when positive,
+	// with sizeFn estimating a value's size.
+	maxBytes int64
+	sizeFn   func(V) int64
+
+	refreshAhead       time.Duration
+	negativeTTL        time.Duration
+	maxConcurrentLoads int
+	// retryAttempts, if above one, is how often a failing loader is called
+	// before giving up, waiting retryBaseDelay before the first retry.
+	retryAttempts  int
+	retryBaseDelay time.Duration
+	// readThrough is the loader set by WithLoader, a func(K) (V,
+	// time.Duration, error) for the cache's key type K. Options do not know K,
+	// so NewCache checks the type.
+	readThrough any
+	// missHook is the hook set by WithOnMiss, a func(K) checked by NewCache
+	// like readThrough.
+	missHook any
+	// batchHandler is the handler set by WithBatchEvictionHandler, a
+	// func(map[K]V, EvictionReason) checked by NewCache like readThrough.
+	batchHandler any
+	// breakerThreshold enables the loader circuit breaker when positive.
+	breakerThreshold int
+	breakerWindow    time.Duration
+	breakerCooldown  time.Duration
+
+	// copier, if set, copies values on their way into and out of the cache.
+	copier func(V) V
+	// panicHandler, if set, receives panics recovered from user callbacks.
+	panicHandler func(recovered any)
+}
+
+// copyValue returns v, or a copy of it made by the configured copier. If the
+// copier panics and WithPanicHandler recovers it, copyValue returns the zero
+// value.
+func (c *config[V]) copyValue(v V) (copied V) {
+	if c.copier == nil {
+		return v
+	}
+	c.guard(func() { copied = c.copier(v) })
+	return copied
+}
+
+func defaultConfig[V any]() config[V] {
+	return config[V]{
+		cleanupInterval: DefaultCleanupInterval,
+		shardCount:      1,
+		clock:           realClock{},
+	}
+}
+
+// WithCleanupInterval sets how often the janitor removes expired items, which
+// defaults to DefaultCleanupInterval. A non-positive interval disables the
+// janitor; expired items are then never served, and are removed when Get finds
+// them, by DeleteExpired, or when they are overwritten or deleted. The interval
+// can be changed later with SetCleanupInterval.
+func WithCleanupInterval[V any](interval time.Duration) Option[V] {
+	return func(c *config[V]) {
+		c.cleanupInterval = interval
+	}
+}
+
+// WithAdaptiveCleanup lets the janitor adapt its interval to the workload,
+// between min and max. A sweep that finds at least a quarter of the entries
+// expired halves the interval, and one that finds at most one in twenty, or an
+// empty cache, doubles it. The janitor starts at the cleanup interval, clamped
+// to the bounds, and is still disabled by a non-positive cleanup interval; the
+// interval in use is reported by CleanupInterval. Bounds that are not positive,
+// or with min above max, are ignored.
+func WithAdaptiveCleanup[V any](min, max time.Duration) Option[V] {
+	return func(c *config[V]) {
+		if min > 0 && min <= max {
+			c.minCleanup, c.maxCleanup = min, max
+		}
+	}
+}
+
+// WithRandomizedCleanup delays the janitor's first sweep by a random fraction
+// of the cleanup interval, after which it sweeps every interval as usual, so
+// that caches created together with the same interval do not all sweep at the
+// same moment. The delay is chosen again whenever SetCleanupInterval restarts
+// a stopped janitor.
+func WithRandomizedCleanup[V any]() Option[V] {
+	return func(c *config[V]) {
+		c.cleanupOffset = randomCleanupOffset
+	}
+}
+
+// WithDefaultTTL sets the expiration applied by Set.
+// A non-positive ttl, which is also the default, means items stored with Set
+// never expire.
+func WithDefaultTTL[V any](ttl time.Duration) Option[V] {
+	return func(c *config[V]) {
+		c.defaultTTL = ttl
+	}
+}
+
+// WithSlidingExpiration makes every successful Get reset the item's expiry to
+// the TTL it was stored with, so frequently read entries stay cached.
+// Items that never expire are unaffected.
+//
+// Sliding expiration forces Get to take the write lock instead of the read
+// lock, which serializes concurrent readers.
+func WithSlidingExpiration[V any]() Option[V] {
+	return func(c *config[V]) {
+		c.sliding = true
+	}
+}
+
+// WithSegmentedTTL lets frequently read entries outlive rarely read ones. An
+// entry starts on probation with the TTL it was stored with. Once Get has
+// found it threshold times it is protected, and from then on every Get that
+// finds it extends its expiry by that TTL again, but never to more than maxTTL
+// from now, so a hot entry stays cached for at most maxTTL after the last
+// read. Unlike WithSlidingExpiration, which restarts the full window on every
+// read, the extensions accumulate and are capped. Storing a new value under
+// the key puts it back on probation. Items that never expire are unaffected,
+// and the option is ignored unless threshold and maxTTL are positive.
+//
+// Counting reads makes Get take the write lock, as with sliding expiration.
+func WithSegmentedTTL[V any](threshold int, maxTTL time.Duration) Option[V] {
+	return func(c *config[V]) {
+		if threshold > 0 && maxTTL > 0 {
+			c.promoteAfter, c.maxPromotedTTL = threshold, maxTTL
+		}
+	}
+}
+
+// WithMaxEntries bounds the cache to n entries using least-recently-used
+// eviction: when Set would exceed n, the entry that was least recently read or
+// written is removed. A non-positive n leaves the cache unbounded.
+//
+// Like sliding expiration, tracking recency makes Get take the write lock.
+// WithMaxEntries, WithLFU and WithFIFO select the eviction policy and are
+// mutually exclusive; the last one wins.
+func WithMaxEntries[V any](n int) Option[V] {
+	return func(c *config[V]) {
+		if n <= 0 {
+			return
+		}
+		c.maxEntries = n
+		c.policy = lruPolicyKind
+	}
+}
+
+// WithLFU bounds the cache to n entries using least-frequently-used eviction:
+// when Set would exceed n, the entry with the fewest reads and writes is
+// removed, and among equally used entries the one that reached that count
+// first. Access counts saturate rather than overflow. A non-positive n leaves
+// the cache unbounded.
+func WithLFU[V any](n int) Option[V] {
+	return func(c *config[V]) {
+		if n <= 0 {
+			return
+		}
+		c.maxEntries = n
+		c.policy = lfuPolicyKind
+	}
+}
+
+// WithFrequencyDecay ages the access counts of an LFU cache by halving them
+// once every interval, so that keys that were hot long ago but are no longer
+// read can be evicted in favour of those in use now. The halving happens
+// during the janitor's sweeps, so it runs at most once per cleanup interval and
+// not at all while the janitor is disabled or paused. It has no effect without
+// WithLFU, or with a non-positive interval.
+func WithFrequencyDecay[V any](interval time.Duration) Option[V] {
+	return func(c *config[V]) {
+		c.decayInterval = interval
+	}
+}
+
+// WithFIFO bounds the cache to n entries using first-in-first-out eviction:
+// when Set would exceed n, the entry that was inserted first is removed,
+// however often it has been read. Overwriting a key keeps its place in the
+// queue; only a key that is deleted or evicted and then stored again moves to
+// the back. Because reads do not reorder anything, Get keeps using the read
+// lock. A non-positive n leaves the cache unbounded.
+func WithFIFO[V any](n int) Option[V] {
+	return func(c *config[V]) {
+		if n <= 0 {
+			return
+		}
+		c.maxEntries = n
+		c.policy = fifoPolicyKind
+	}
+}
+
+// WithMaxCost bounds the total cost of the cache's values, as reported by cost,
+// to max. When Set would exceed it, entries are evicted until the new item fits,
+// least recently used first unless WithLFU or WithFIFO selected another
+// policy. An item whose own cost exceeds max, or with WithShards its shard's
+// share of max, is rejected by Set. A non-positive max or a nil cost function
+// leaves the cache unbounded by cost.
+func WithMaxCost[V any](max int64, cost func(V) int64) Option[V] {
+	return func(c *config[V]) {
+		if max <= 0 || cost == nil {
+			return
+		}
+		c.maxCost = max
+		c.costFn = cost
+		if c.policy == noPolicy {
+			c.policy = lruPolicyKind
+		}
+	}
+}
+
+// WithMaxBytes bounds the estimated memory held by the cache's entries to n
+// bytes. When Set would exceed it, entries are evicted until the new item fits,
+// least recently used first unless WithLFU or WithFIFO selected another policy.
+// An item whose own estimated size exceeds n, or with WithShards its shard's
+// share of n, is rejected by Set. Sizes are estimated by the function given to
+// WithSizeEstimator, or else from the in-memory size of the value and key types
+// plus the length of string values and keys; the default estimate does not
+// follow pointers, so set an estimator for values that reference other memory.
+// A non-positive n leaves the cache unbounded.
+func WithMaxBytes[V any](n int64) Option[V] {
+	return func(c *config[V]) {
+		if n <= 0 {
+			return
+		}
+		c.maxBytes = n
+		if c.policy == noPolicy {
+			c.policy = lruPolicyKind
+		}
+	}
+}
+
+// WithSizeEstimator sets the function WithMaxBytes uses to estimate the size
+// of a value in bytes. The size of the key is added to its result. A nil
+// function restores the default estimate.
+func WithSizeEstimator[V any](size func(V) int64) Option[V] {
+	return func(c *config[V]) {
+		c.sizeFn = size
+	}
+}
+
+// WithCopier makes the cache copy values with copier as they are stored and
+// again as they are returned, so that callers and the cache never share
+// mutable state such as a slice's backing array or a pointed-to struct. Values
+// are stored as copier(value), and reads such as Get, Peek, GetMany, Items and
+// Range return copier(stored). Values passed to eviction callbacks and
+// subscribers are not copied. Without this option values are handed out as
+// stored, with no copying; enable it only for mutable value types, as every
+// store and read pays for a copy. A nil copier is ignored.
+func WithCopier[V any](copier func(V) V) Option[V] {
+	return func(c *config[V]) {
+		if copier != nil {
+			c.copier = copier
+		}
+	}
+}
+
+// WithClock sets the clock used to compute and check expiry times, which
+// defaults to the system clock. The janitor still sweeps on a real-time
+// ticker, but decides what has expired using clock. A nil clock is ignored.
+func WithClock[V any](clock Clock) Option[V] {
+	return func(c *config[V]) {
+		if clock != nil {
+			c.clock = clock
+		}
+	}
+}
+
+// WithNowFunc is a lighter alternative to WithClock for callers that only need
+// to control the current time, e.g. in tests: now replaces time.Now wherever
+// the cache computes or checks an expiry, including Set, Get and the janitor.
+// As with WithClock, the janitor still sweeps on a real-time ticker. A nil now
+// is ignored; WithNowFunc and WithClock override each other, the last one
+// winning.
+func WithNowFunc[V any](now func() time.Time) Option[V] {
+	return func(c *config[V]) {
+		if now != nil {
+			c.clock = clockFunc(now)
+		}
+	}
+}
+
+// WithShards partitions the cache into n shards, each guarded by its own lock,
+// to reduce contention on multi-core machines. Keys are assigned to shards by
+// hash; single-key operations lock only their key's shard, while aggregate
+// operations such as Len, Keys and Clear lock every shard in a fixed order.
+//
+// Capacity limits set by WithMaxEntries, WithLFU, WithFIFO, WithMaxCost and
+// WithMaxBytes are divided evenly between shards and enforced per shard, so
+// eviction order is only approximately global, and a single item must fit
+// within its shard's share. Every shard holds at least one entry, so a limit
+// below n lets the cache hold up to n entries. Values of n below 2 keep a
+// single shard.
+func WithShards[V any](n int) Option[V] {
+	return func(c *config[V]) {
+		if n > 1 {
+			c.shardCount = n
+		}
+	}
+}
+
+// WithInitialCapacity presizes the cache to hold about n entries without
+// growing, which avoids repeated rehashing while a large cache is filled at
+// startup. It is only a hint: the cache still grows beyond n entries, and
+// Clear does not keep the capacity. With WithShards, n is divided evenly
+// between the shards. A negative n is ignored; the default of 0 sizes the
+// cache on demand.
+func WithInitialCapacity[V any](n int) Option[V] {
+	return func(c *config[V]) {
+		if n >= 0 {
+			c.initialCapacity = n
+		}
+	}
+}
+
+// WithJitter spreads out expirations by randomly moving each item's expiry by
+// up to ±fraction of its TTL when it is stored, so entries written together
+// with the same TTL do not all expire, and get reloaded, at once. The random
+// source is safe for concurrent use. A fraction of 0, the default, disables
+// jitter; fractions are clamped to [0, 1].
+func WithJitter[V any](fraction float64) Option[V] {
+	return func(c *config[V]) {
+		c.jitter = min(max(fraction, 0), 1)
+	}
+}
+
+// WithRefreshAhead makes GetOrLoad refresh values before they expire, as well
+// as Get, GetContext and Fetch with WithLoader: when one of them finds a live
+// value with less than window of its TTL remaining, it returns that value
+// immediately and calls the loader in a background goroutine to replace it. At
+// most one load per key runs at a time, and a failed refresh leaves the current
+// value in place until it expires. Items that never expire are not refreshed. A
+// non-positive window, the default, disables refreshing.
+func WithRefreshAhead[V any](window time.Duration) Option[V] {
+	return func(c *config[V]) {
+		c.refreshAhead = window
+	}
+}
+
+// WithNegativeCaching makes GetOrLoad cache loader misses for ttl: when the
+// loader returns an error matching ErrNotFound, a negative entry is stored so
+// that further GetOrLoad calls for the key return ErrNotFound without invoking
+// the loader until the entry expires. Get, Has and other reads report negative
+// entries as absent, they are never passed to the eviction callback, and
+// storing a value for the key replaces them. A non-positive ttl, the default,
+// disables negative caching.
+func WithNegativeCaching[V any](ttl time.Duration) Option[V] {
+	return func(c *config[V]) {
+		c.negativeTTL = ttl
+	}
+}
+
+// WithMaxConcurrentLoads caps how many loaders passed to GetOrLoad, including
+// refreshes started by WithRefreshAhead, run at once across all keys, so a
+// burst of misses cannot overwhelm the backend. Further loads wait for a free
+// slot; GetOrLoadContext stops waiting, returning the context's error, once its
+// context is done, and so do concurrent callers for the same key, which share
+// that load. Stats reports the number of loads running in InFlightLoads. A
+// non-positive n, the default, leaves loads unlimited.
+func WithMaxConcurrentLoads[V any](n int) Option[V] {
+	return func(c *config[V]) {
+		if n > 0 {
+			c.maxConcurrentLoads = n
+		}
+	}
+}
+
+// WithCircuitBreaker stops GetOrLoad from calling loaders while the backing
+// store appears to be down. After threshold consecutive loader errors, each
+// within window of the first, the circuit opens: for cooldown, GetOrLoad returns
+// ErrCircuitOpen on a miss without calling the loader. Once cooldown has
+// elapsed, a single probe load is let through; if it succeeds the circuit
+// closes, and if it fails the circuit opens for another cooldown. Loaders
+// returning ErrNotFound count as successes, and context.Canceled is ignored.
+// The breaker covers all keys of the cache together. Stats reports its state
+// in CircuitState and how often it has opened in CircuitOpens.
+//
+// A non-positive window counts consecutive errors however far apart they are.
+// A non-positive threshold or cooldown leaves the breaker disabled.
+func WithCircuitBreaker[V any](threshold int, window, cooldown time.Duration) Option[V] {
+	return func(c *config[V]) {
+		if threshold <= 0 || cooldown <= 0 {
+			return
+		}
+		c.breakerThreshold = threshold
+		c.breakerWindow = window
+		c.breakerCooldown = cooldown
+	}
+}
+
+// WithLoader makes the cache read-through: when Get, GetContext or Fetch miss,
+// loader is called to produce the key's value and the TTL to store it with, and
+// the result is stored and returned. Concurrent misses on the same key share a
+// single loader call, and loads are subject to WithNegativeCaching,
+// WithMaxConcurrentLoads and WithCircuitBreaker as for GetOrLoad. A
+// non-positive TTL from loader stores the value as Set does, with the default
+// TTL if one is configured. Peek, Has and the other lookups never load.
+//
+// The key type of loader must match that of the cache, which is inferred from
+// loader, e.g. WithLoader(func(key string) (User, time.Duration, error) {...})
+// for a SimpleCache[User]; NewCache panics if it does not. A nil loader is
+// ignored.
+func WithLoader[K comparable, V any](loader func(key K) (V, time.Duration, error)) Option[V] {
+	return func(c *config[V]) {
+		if loader != nil {
+			c.readThrough = loader
+		}
+	}
+}
+
+// WithOnMiss registers hook to be called with the key of every Get miss, e.g.
+// to sample keys that are often requested but absent as candidates for
+// warming the cache. It fires both for keys that are not stored at all and for
+// keys whose value has expired, which Get removes before calling hook, and
+// also for the misses of GetContext and of GetOrLoad before they load. Other
+// lookups, such as Peek, GetMany or GetWithExpiry, do not call it.
+//
+// hook runs on the goroutine that missed, after the cache's lock has been
+// released, so it must be cheap: the Get that missed returns once it does. The
+// key type is inferred from hook, e.g. WithOnMiss[User](func(key string) {...})
+// for a SimpleCache[User], and must match the cache's; NewCache panics if it
+// does not. A nil hook is ignored.
+func WithOnMiss[V any, K comparable](hook func(key K)) Option[V] {
+	return func(c *config[V]) {
+		if hook != nil {
+			c.missHook = hook
+		}
+	}
+}
+
+// WithBatchEvictionHandler registers handler to receive all the items removed
+// by a single sweep of the janitor, or by DeleteExpired, in one call, keyed by
+// key and with ReasonExpired, e.g. to clean up after them downstream with one
+// bulk request rather than one per item. handler is not called for a sweep
+// that removes nothing. It runs on the sweeping goroutine once every shard
+// lock has been released, so it may call back into the cache, but the next
+// sweep waits for it to return. The map is owned by handler.
+//
+// Items removed by a sweep go to handler instead of the per-item callback set
+// with OnEvicted, which still receives every other removal, including expired
+// items found by Get. Subscribers receive their events as usual. The key type
+// of handler must match the cache's; NewCache panics if it does not. A nil
+// handler is ignored.
+func WithBatchEvictionHandler[K comparable, V any](handler func(items map[K]V, reason EvictionReason)) Option[V] {
+	return func(c *config[V]) {
+		if handler != nil {
+			c.batchHandler = handler
+		}
+	}
+}
+
+// WithPanicHandler recovers panics in the user-supplied functions the cache
+// calls on its own: the eviction callback set with OnEvicted, the
+// WithBatchEvictionHandler handler, the WithOnMiss hook, loaders, copiers and
+// cost and size estimators. The recovered value is passed to handler, so that
+// a panicking callback can stop neither the janitor, nor a background refresh,
+// nor the goroutine that triggered it. A loader that panics fails its load with
+// ErrLoaderFailed, and is not retried. A value whose estimator or copier panics
+// while it is being stored is not stored, and a copier that panics while
+// copying a value out makes the read return the zero value.
+// handler runs on the goroutine that panicked and must not panic itself.
+//
+// Without it, such panics propagate to the caller, or crash the program if
+// they happen on the janitor's or a refresh's goroutine. A nil handler is
+// ignored.
+func WithPanicHandler[V any](handler func(recovered any)) Option[V] {
+	return func(c *config[V]) {
+		if handler != nil {
+			c.panicHandler = handler
+		}
+	}
+}
+
+// WithLoaderRetry retries a loader that fails on a miss in GetOrLoad, or with
+// WithLoader, calling it up to attempts times in all. The wait before the
+// first retry is baseDelay, and each following wait is twice the one before.
+// Retries stop early once the context of the caller that started the load is
+// done, or when its deadline would pass before the next attempt. A loader that
+// returns ErrNotFound is not retried. Concurrent callers still share a single
+// load, retries included, and only its final outcome counts towards the
+// circuit breaker. If every attempt fails, the last error is returned, wrapped
+// in ErrLoaderFailed. Background refreshes started by WithRefreshAhead are
+// retried too. An attempts value of one or less disables retrying.
+func WithLoaderRetry[V any](attempts int, baseDelay time.Duration) Option[V] {
+	return func(c *config[V]) {
+		c.retryAttempts = attempts
+		c.retryBaseDelay = max(baseDelay, 0)
+	}
+}
@@ -0,0 +1,118 @@
+// Package oteltrace records OpenTelemetry spans for loader-backed lookups on a
+// keyvalstore cache, so that slow loads show up in traces. It lives in its own
+// module so that only programs that use it depend on OpenTelemetry.
+package oteltrace
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	keyvalstore "github.com/peeperklip/simplecache"
+)
+
+// SpanName is the name of the spans started by GetOrLoad.
+const SpanName = "keyvalstore.GetOrLoad"
+
+// Attribute keys set on every span.
+const (
+	// KeyAttribute holds the looked-up key, formatted with fmt.Sprint, or
+	// RedactedKey if keys are redacted.
+	KeyAttribute = attribute.Key("cache.key")
+	// ResultAttribute holds ResultHit, ResultMiss or ResultError.
+	ResultAttribute = attribute.Key("cache.result")
+)
+
+// Values of ResultAttribute.
+const (
+	ResultHit   = "hit"
+	ResultMiss  = "miss"
+	ResultError = "error"
+)
+
+// RedactedKey replaces the key attribute when WithRedactedKeys is set.
+const RedactedKey = "[redacted]"
+
+// Tracer starts spans for cache lookups. A nil *Tracer, or one created with a
+// nil trace.Tracer, records nothing.
+type Tracer struct {
+	tracer trace.Tracer
+	redact bool
+}
+
+// Option configures a Tracer.
+type Option func(*Tracer)
+
+// WithRedactedKeys records RedactedKey instead of the looked-up key, for keys
+// that carry personal or secret data.
+func WithRedactedKeys() Option {
+	return func(t *Tracer) {
+		t.redact = true
+	}
+}
+
+// New creates a Tracer that starts spans with tracer.
+func New(tracer trace.Tracer, opts ...Option) *Tracer {
+	t := &Tracer{tracer: tracer}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// GetOrLoad calls c.GetOrLoadContext within a span named SpanName, passing the
+// span's context to loader so that spans it starts are nested under it. The
+// span records the key and whether the lookup was a hit, a miss that ran
+// loader, or an error, in which case the error is also recorded on the span. A
+// call that waits for a load started by another caller is recorded as a hit,
+// as it does not run loader itself. Conversely, a hit whose background refresh
+// (see keyvalstore.WithRefreshAhead) starts running before GetOrLoad returns
+// may be recorded as a miss. With a nil t, GetOrLoad only calls
+// c.GetOrLoadContext.
+func GetOrLoad[K comparable, V any](ctx context.Context, t *Tracer, c *keyvalstore.Cache[K, V], key K, ttl time.Duration, loader func(ctx context.Context) (V, error)) (V, error) {
+	if t == nil || t.tracer == nil {
+		return c.GetOrLoadContext(ctx, key, ttl, loader)
+	}
+
+	ctx, span := t.tracer.Start(ctx, SpanName, trace.WithAttributes(KeyAttribute.String(t.keyValue(key))))
+	defer span.End()
+
+	// With WithRefreshAhead, loader may also run in the background after a
+	// hit, so only loads that start before GetOrLoadContext returns count.
+	var state atomic.Int32
+	v, err := c.GetOrLoadContext(ctx, key, ttl, func(ctx context.Context) (V, error) {
+		state.CompareAndSwap(pending, loaded)
+		return loader(ctx)
+	})
+	state.CompareAndSwap(pending, returned)
+	switch {
+	case err != nil:
+		span.SetAttributes(ResultAttribute.String(ResultError))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	case state.Load() == loaded:
+		span.SetAttributes(ResultAttribute.String(ResultMiss))
+	default:
+		span.SetAttributes(ResultAttribute.String(ResultHit))
+	}
+	return v, err
+}
+
+// States of a GetOrLoad call, tracking whether it ran loader.
+const (
+	pending int32 = iota
+	loaded
+	returned
+)
+
+func (t *Tracer) keyValue(key any) string {
+	if t.redact {
+		return RedactedKey
+	}
+	return fmt.Sprint(key)
+}
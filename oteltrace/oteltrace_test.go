@@ -0,0 +1,115 @@
+package oteltrace
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	keyvalstore "github.com/peeperklip/simplecache"
+)
+
+func newRecorder() (*tracetest.SpanRecorder, *sdktrace.TracerProvider) {
+	recorder := tracetest.NewSpanRecorder()
+	return recorder, sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+}
+
+func attributeValue(span sdktrace.ReadOnlySpan, key attribute.Key) string {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func TestGetOrLoad_RecordsResults(t *testing.T) {
+	recorder, provider := newRecorder()
+	sut := New(provider.Tracer("test"))
+	cache := keyvalstore.NewSimpleCache[string](0)
+	ctx := context.Background()
+	failure := errors.New("backend down")
+
+	load := func(context.Context) (string, error) { return "value1", nil }
+	if v, err := GetOrLoad(ctx, sut, cache, "key1", time.Minute, load); err != nil || v != "value1" {
+		t.Errorf("Expected the loaded value, got '%s', err: %v", v, err)
+	}
+	if v, err := GetOrLoad(ctx, sut, cache, "key1", time.Minute, load); err != nil || v != "value1" {
+		t.Errorf("Expected the cached value, got '%s', err: %v", v, err)
+	}
+	_, err := GetOrLoad(ctx, sut, cache, "key2", time.Minute, func(context.Context) (string, error) {
+		return "", failure
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected the loader's error, got %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans, got %d", len(spans))
+	}
+	expected := []struct{ key, result string }{{"key1", ResultMiss}, {"key1", ResultHit}, {"key2", ResultError}}
+	for i, span := range spans {
+		if span.Name() != SpanName {
+			t.Errorf("Expected span %d to be named %s, got %s", i, SpanName, span.Name())
+		}
+		if key := attributeValue(span, KeyAttribute); key != expected[i].key {
+			t.Errorf("Expected span %d to record key %s, got %s", i, expected[i].key, key)
+		}
+		if result := attributeValue(span, ResultAttribute); result != expected[i].result {
+			t.Errorf("Expected span %d to record result %s, got %s", i, expected[i].result, result)
+		}
+	}
+	if spans[2].Status().Code != codes.Error || len(spans[2].Events()) == 0 {
+		t.Errorf("Expected the failed load's span to record the error")
+	}
+}
+
+func TestGetOrLoad_NestsLoaderSpans(t *testing.T) {
+	recorder, provider := newRecorder()
+	tracer := provider.Tracer("test")
+	cache := keyvalstore.NewSimpleCache[string](0)
+
+	_, _ = GetOrLoad(context.Background(), New(tracer), cache, "key1", time.Minute, func(ctx context.Context) (string, error) {
+		_, span := tracer.Start(ctx, "load")
+		span.End()
+		return "value1", nil
+	})
+
+	spans := recorder.Ended()
+	if len(spans) != 2 || spans[0].Parent().SpanID() != spans[1].SpanContext().SpanID() {
+		t.Errorf("Expected the loader's span to be a child of the lookup span")
+	}
+}
+
+func TestGetOrLoad_RedactedKeys(t *testing.T) {
+	recorder, provider := newRecorder()
+	sut := New(provider.Tracer("test"), WithRedactedKeys())
+	cache := keyvalstore.NewCache[int, string](keyvalstore.WithCleanupInterval[string](0))
+
+	_, _ = GetOrLoad(context.Background(), sut, cache, 42, time.Minute, func(context.Context) (string, error) {
+		return "value1", nil
+	})
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || attributeValue(spans[0], KeyAttribute) != RedactedKey {
+		t.Errorf("Expected the key to be redacted")
+	}
+}
+
+func TestGetOrLoad_WithoutTracer(t *testing.T) {
+	cache := keyvalstore.NewSimpleCache[string](0)
+	for _, sut := range []*Tracer{nil, New(nil)} {
+		v, err := GetOrLoad(context.Background(), sut, cache, "key1", time.Minute, func(context.Context) (string, error) {
+			return "value1", nil
+		})
+		if err != nil || v != "value1" {
+			t.Errorf("Expected GetOrLoad to work without a tracer, got '%s', err: %v", v, err)
+		}
+	}
+}
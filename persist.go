@@ -0,0 +1,133 @@
+package keyvalstore
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+// jsonEntry is the serialized form of a cache entry.
+type jsonEntry[K comparable, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+	// TTL is the remaining lifetime in nanoseconds, omitted for entries that
+	// never expire.
+	TTL *time.Duration `json:"ttl,omitempty"`
+}
+
+// MarshalJSON encodes the live entries of the cache together with their
+// remaining TTLs, for use with LoadFromJSON or LoadCacheFromJSON. K and V must
+// be encodable with encoding/json.
+func (c *Cache[K, V]) MarshalJSON() ([]byte, error) {
+	c.rlockAll()
+	now := c.clock.Now()
+	entries := make([]jsonEntry[K, V], 0, c.storedLocked())
+	for _, s := range c.shards {
+		for k, it := range s.data {
+			if !it.live(now) {
+				continue
+			}
+			entry := jsonEntry[K, V]{Key: k, Value: it.value}
+			if !it.expiryTime.IsZero() {
+				ttl := it.expiryTime.Sub(now)
+				entry.TTL = &ttl
+			}
+			entries = append(entries, entry)
+		}
+	}
+	c.runlockAll()
+
+	return json.Marshal(entries)
+}
+
+// LoadFromJSON creates a cache, as NewSimpleCache does, populated from data
+// produced by MarshalJSON. Entries whose remaining TTL has elapsed are dropped
+// rather than resurrected. T must be decodable with encoding/json.
+func LoadFromJSON[T any](data []byte, cleanupInterval time.Duration, opts ...Option[T]) (*SimpleCache[T], error) {
+	return LoadCacheFromJSON[string](data, append([]Option[T]{WithCleanupInterval[T](cleanupInterval)}, opts...)...)
+}
+
+// LoadCacheFromJSON is like LoadFromJSON for caches with keys of type K,
+// creating the cache as NewCache does. K and V must be decodable with
+// encoding/json.
+func LoadCacheFromJSON[K comparable, V any](data []byte, opts ...Option[V]) (*Cache[K, V], error) {
+	var entries []jsonEntry[K, V]
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	c := NewCache[K](opts...)
+	for _, e := range entries {
+		switch {
+		case e.TTL == nil:
+			c.SetForever(e.Key, e.Value)
+		case *e.TTL > 0:
+			c.SetWithTTL(e.Key, e.Value, *e.TTL)
+		}
+	}
+	return c, nil
+}
+
+// gobEntry is the binary form of a cache entry written by Save.
+type gobEntry[K comparable, V any] struct {
+	Key   K
+	Value V
+	// ExpiryTime is the absolute expiry, zero for entries that never expire.
+	ExpiryTime time.Time
+	TTL        time.Duration
+}
+
+// Save writes the live entries of the cache, with their absolute expiry times,
+// to w using encoding/gob. K and V must be encodable with gob; interface values
+// require their concrete types to be registered with gob.Register.
+func (c *Cache[K, V]) Save(w io.Writer) error {
+	c.rlockAll()
+	now := c.clock.Now()
+	entries := make([]gobEntry[K, V], 0, c.storedLocked())
+	for _, s := range c.shards {
+		for k, it := range s.data {
+			if it.live(now) {
+				entries = append(entries, gobEntry[K, V]{Key: k, Value: it.value, ExpiryTime: it.expiryTime, TTL: it.ttl})
+			}
+		}
+	}
+	c.runlockAll()
+
+	return gob.NewEncoder(w).Encode(entries)
+}
+
+// Load merges entries written by Save from r into the cache. Entries that have
+// expired since they were saved are skipped. Loaded entries overwrite existing
+// entries with the same key; other existing entries are kept. It returns
+// ErrClosed, loading nothing, if the cache is closed.
+func (c *Cache[K, V]) Load(r io.Reader) error {
+	if c.closed.Load() {
+		return ErrClosed
+	}
+	var entries []gobEntry[K, V]
+	if err := gob.NewDecoder(r).Decode(&entries); err != nil {
+		return err
+	}
+
+	now := c.clock.Now()
+	items := make(map[K]cacheItem[V], len(entries))
+	for _, e := range entries {
+		// Decoded times carry no monotonic clock reading, so convert each
+		// expiry into a deadline relative to now, which does.
+		expiryTime := e.ExpiryTime
+		if !expiryTime.IsZero() {
+			expiryTime = now.Add(expiryTime.Sub(now))
+		}
+		item, err := c.newItem(e.Key, e.Value, e.TTL, expiryTime)
+		if errors.Is(err, ErrClosed) {
+			return err
+		}
+		if err == nil && !item.expired(now) {
+			items[e.Key] = item
+		}
+	}
+	c.storeBatch(items)
+	return nil
+}
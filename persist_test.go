@@ -0,0 +1,109 @@
+package keyvalstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSimpleCache_JSONRoundTrip(t *testing.T) {
+	type session struct {
+		User  string
+		Roles []string
+	}
+	sut := NewSimpleCache[session](1 * time.Minute)
+	defer sut.Close()
+
+	sut.SetWithTTL("alice", session{User: "alice", Roles: []string{"admin"}}, time.Minute)
+	sut.SetForever("bob", session{User: "bob"})
+	sut.SetWithTTL("expired", session{User: "carol"}, -time.Second)
+
+	data, err := json.Marshal(sut)
+	if err != nil {
+		t.Fatalf("Expected no error marshaling the cache, got %v", err)
+	}
+
+	loaded, err := LoadFromJSON[session](data, 1*time.Minute)
+	if err != nil {
+		t.Fatalf("Expected no error loading the cache, got %v", err)
+	}
+	defer loaded.Close()
+
+	if n := loaded.Len(); n != 2 {
+		t.Errorf("Expected 2 entries after loading, got %d", n)
+	}
+	alice, found := loaded.Get("alice")
+	if !found || alice.User != "alice" || len(alice.Roles) != 1 || alice.Roles[0] != "admin" {
+		t.Errorf("Expected alice to round-trip, got %+v, found: %v", alice, found)
+	}
+	if ttl, _ := loaded.TTL("alice"); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("Expected alice to keep a remaining TTL within (0, 1m], got %v", ttl)
+	}
+	if ttl, _ := loaded.TTL("bob"); ttl != NoExpiration {
+		t.Errorf("Expected bob to keep never expiring, got %v", ttl)
+	}
+}
+
+func TestLoadFromJSONDropsElapsedEntries(t *testing.T) {
+	data := []byte(`[{"key":"stale","value":"v","ttl":-1000},{"key":"fresh","value":"v","ttl":60000000000}]`)
+
+	loaded, err := LoadFromJSON[string](data, 1*time.Minute)
+	if err != nil {
+		t.Fatalf("Expected no error loading the cache, got %v", err)
+	}
+	defer loaded.Close()
+
+	if loaded.Has("stale") {
+		t.Errorf("Expected an elapsed entry to be dropped on load")
+	}
+	if !loaded.Has("fresh") {
+		t.Errorf("Expected a fresh entry to be loaded")
+	}
+}
+
+func TestSimpleCache_GobRoundTrip(t *testing.T) {
+	type profile struct {
+		Name  string
+		Age   int
+		Tags  []string
+		Prefs map[string]bool
+	}
+	sut := NewSimpleCache[profile](1 * time.Minute)
+	defer sut.Close()
+
+	alice := profile{Name: "alice", Age: 30, Tags: []string{"a", "b"}, Prefs: map[string]bool{"dark": true}}
+	sut.SetWithTTL("alice", alice, time.Minute)
+	sut.SetForever("bob", profile{Name: "bob"})
+	sut.SetWithTTL("expired", profile{Name: "carol"}, -time.Second)
+
+	var buf bytes.Buffer
+	if err := sut.Save(&buf); err != nil {
+		t.Fatalf("Expected no error saving the cache, got %v", err)
+	}
+
+	loaded := NewSimpleCache[profile](1 * time.Minute)
+	defer loaded.Close()
+	loaded.Set("existing", profile{Name: "dave"})
+	loaded.Set("bob", profile{Name: "stale bob"})
+	if err := loaded.Load(&buf); err != nil {
+		t.Fatalf("Expected no error loading the cache, got %v", err)
+	}
+
+	got, found := loaded.Get("alice")
+	if !found || got.Name != alice.Name || got.Age != alice.Age || len(got.Tags) != 2 || !got.Prefs["dark"] {
+		t.Errorf("Expected alice to round-trip as %+v, got %+v, found: %v", alice, got, found)
+	}
+	if got, _ = loaded.Get("bob"); got.Name != "bob" {
+		t.Errorf("Expected loaded bob to overwrite the existing entry, got %+v", got)
+	}
+	if ttl, _ := loaded.TTL("bob"); ttl != NoExpiration {
+		t.Errorf("Expected bob to keep never expiring, got %v", ttl)
+	}
+	if !loaded.Has("existing") {
+		t.Errorf("Expected existing entries to be kept by Load")
+	}
+	if loaded.Has("expired") {
+		t.Errorf("Expected expired entries not to be saved")
+	}
+}
@@ -0,0 +1,132 @@
+package keyvalstore
+
+import (
+	"strings"
+	"time"
+)
+
+// DeletePrefix removes every entry whose key begins with prefix, reporting
+// each to the eviction callback with ReasonDeleted, and returns the number of
+// live entries removed. Expired entries under the prefix are removed too, and
+// reported with ReasonExpired as the janitor would.
+//
+// Keys are not kept in order, so DeletePrefix scans every entry: it is O(n) in
+// the size of the cache and holds each shard's write lock while scanning it.
+// It is a function rather than a method because it requires string keys.
+func DeletePrefix[K ~string, V any](c *Cache[K, V], prefix string) int {
+	n := 0
+	var removed []evictedEntry[K, V]
+	for _, s := range c.shards {
+		s.mutex.Lock()
+		now := c.clock.Now()
+		for key := range s.data {
+			if !strings.HasPrefix(string(key), prefix) {
+				continue
+			}
+			var live bool
+			removed, live = c.deleteLocked(s, key, now, removed)
+			if live {
+				n++
+			}
+		}
+		s.mutex.Unlock()
+	}
+
+	c.notifyEvicted(removed)
+	return n
+}
+
+// PrefixedCache is a view of a Cache restricted to the keys that begin with a
+// prefix, created with Prefixed. Its methods add the prefix to the keys they
+// are given and strip it from the keys they return, so a component handed a
+// PrefixedCache sees only its own namespace. The view shares the parent's
+// storage, limits and callbacks, which see the full keys: entries stored
+// through the view can be read from the parent under their prefixed keys, and
+// are removed by DeletePrefix on the parent like any other.
+type PrefixedCache[K ~string, V any] struct {
+	parent *Cache[K, V]
+	prefix string
+}
+
+// Prefixed returns a view of c restricted to the keys that begin with prefix.
+// It is a function rather than a method because it requires string keys.
+func Prefixed[K ~string, V any](c *Cache[K, V], prefix string) *PrefixedCache[K, V] {
+	return &PrefixedCache[K, V]{parent: c, prefix: prefix}
+}
+
+// Prefix returns the prefix the view adds to its keys.
+func (p *PrefixedCache[K, V]) Prefix() string {
+	return p.prefix
+}
+
+func (p *PrefixedCache[K, V]) key(key K) K {
+	return K(p.prefix) + key
+}
+
+// Get retrieves the value stored under key in the view, as Cache.Get does.
+func (p *PrefixedCache[K, V]) Get(key K) (V, bool) {
+	return p.parent.Get(p.key(key))
+}
+
+// Has reports whether a live entry exists for key in the view.
+func (p *PrefixedCache[K, V]) Has(key K) bool {
+	return p.parent.Has(p.key(key))
+}
+
+// Set stores value under key in the view, as Cache.Set does.
+func (p *PrefixedCache[K, V]) Set(key K, value V) bool {
+	return p.parent.Set(p.key(key), value)
+}
+
+// SetWithTTL stores value under key in the view with an expiration time, as
+// Cache.SetWithTTL does.
+func (p *PrefixedCache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) bool {
+	return p.parent.SetWithTTL(p.key(key), value, ttl)
+}
+
+// Delete removes key from the view. It is a no-op if the key is absent.
+func (p *PrefixedCache[K, V]) Delete(key K) {
+	p.parent.Delete(p.key(key))
+}
+
+// Clear removes every entry in the view, leaving the rest of the parent cache
+// alone, and returns the number of live entries removed, as DeletePrefix does.
+func (p *PrefixedCache[K, V]) Clear() int {
+	return DeletePrefix(p.parent, p.prefix)
+}
+
+// Keys returns a snapshot of the live keys in the view, without the prefix, in
+// no particular order. Like DeletePrefix, it scans every entry of the parent.
+func (p *PrefixedCache[K, V]) Keys() []K {
+	keys := []K{}
+	p.scan(func(key K, _ V) {
+		keys = append(keys, key)
+	})
+	return keys
+}
+
+// Items returns a snapshot of the live entries in the view, keyed without the
+// prefix. Like DeletePrefix, it scans every entry of the parent.
+func (p *PrefixedCache[K, V]) Items() map[K]V {
+	items := make(map[K]V)
+	p.scan(func(key K, value V) {
+		items[key] = value
+	})
+	return items
+}
+
+// scan calls fn with every live entry in the view, its key stripped of the
+// prefix, while holding every shard's read lock.
+func (p *PrefixedCache[K, V]) scan(fn func(key K, value V)) {
+	c := p.parent
+	c.rlockAll()
+	defer c.runlockAll()
+	now := c.clock.Now()
+	for _, s := range c.shards {
+		for key, it := range s.data {
+			if rest, ok := strings.CutPrefix(string(key), p.prefix); ok && it.live(now) {
+				fn(K(rest), c.copyValue(it.value))
+			}
+		}
+	}
+}
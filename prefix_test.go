@@ -0,0 +1,111 @@
+package keyvalstore
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestDeletePrefix(t *testing.T) {
+	sut := NewSimpleCache(0, WithShards[string](4))
+	defer sut.Close()
+	var evicted []string
+	sut.OnEvicted(func(key string, value string, reason EvictionReason) {
+		evicted = append(evicted, key+":"+reason.String())
+	})
+
+	sut.Set("user:42:profile", "profile")
+	sut.Set("user:42:settings", "settings")
+	sut.SetWithTTL("user:42:session", "session", -time.Second)
+	sut.Set("user:420:profile", "other")
+	sut.Set("group:42", "group")
+
+	if n := DeletePrefix(sut, "user:42:"); n != 2 {
+		t.Errorf("Expected 2 live entries to be deleted, got %d", n)
+	}
+	keys := sut.Keys()
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "group:42" || keys[1] != "user:420:profile" {
+		t.Errorf("Expected only keys outside the prefix to remain, got %v", keys)
+	}
+	if isStored(sut, "user:42:session") {
+		t.Errorf("Expected the expired entry under the prefix to be removed")
+	}
+	sort.Strings(evicted)
+	want := []string{"user:42:profile:deleted", "user:42:session:expired", "user:42:settings:deleted"}
+	if len(evicted) != len(want) {
+		t.Fatalf("Expected %v to be reported, got %v", want, evicted)
+	}
+	for i := range want {
+		if evicted[i] != want[i] {
+			t.Errorf("Expected %v to be reported, got %v", want, evicted)
+		}
+	}
+}
+
+func TestDeletePrefixNamedKeyType(t *testing.T) {
+	type userKey string
+	sut := NewCache[userKey, int](WithCleanupInterval[int](0))
+	defer sut.Close()
+
+	sut.Set("user:1", 1)
+	sut.Set("admin:1", 2)
+	if n := DeletePrefix(sut, "user:"); n != 1 || sut.Has("user:1") || !sut.Has("admin:1") {
+		t.Errorf("Expected DeletePrefix to work with named string key types, removed %d", n)
+	}
+}
+
+func TestPrefixed(t *testing.T) {
+	sut := NewSimpleCache(0, WithShards[string](4))
+	users := Prefixed(sut, "user:")
+
+	users.Set("42", "alice")
+	users.SetWithTTL("43", "bob", time.Minute)
+	users.SetWithTTL("44", "expired", -time.Second)
+	sut.Set("order:42", "order")
+
+	if val, ok := users.Get("42"); !ok || val != "alice" {
+		t.Errorf("Expected 'alice', got '%s' (found: %v)", val, ok)
+	}
+	if val, ok := sut.Get("user:42"); !ok || val != "alice" {
+		t.Errorf("Expected the parent to see the prefixed key, got '%s' (found: %v)", val, ok)
+	}
+	if users.Has("order:42") || users.Has("44") {
+		t.Errorf("Expected the view to see only its own live keys")
+	}
+
+	keys := users.Keys()
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "42" || keys[1] != "43" {
+		t.Errorf("Expected keys [42 43] without the prefix, got %v", keys)
+	}
+	if items := users.Items(); len(items) != 2 || items["43"] != "bob" {
+		t.Errorf("Expected the view's 2 items keyed without the prefix, got %v", items)
+	}
+
+	users.Delete("43")
+	if sut.Has("user:43") {
+		t.Errorf("Expected Delete on the view to remove the prefixed key")
+	}
+	DeletePrefix(sut, "user:")
+	if users.Has("42") {
+		t.Errorf("Expected DeletePrefix on the parent to clear the view")
+	}
+	if !sut.Has("order:42") {
+		t.Errorf("Expected keys outside the view to be left alone")
+	}
+}
+
+func TestPrefixedCache_Clear(t *testing.T) {
+	sut := NewSimpleCache[string](0)
+	users := Prefixed(sut, "user:")
+	users.Set("42", "alice")
+	sut.Set("order:42", "order")
+
+	if n := users.Clear(); n != 1 {
+		t.Errorf("Expected Clear to remove 1 entry, got %d", n)
+	}
+	if sut.Len() != 1 || !sut.Has("order:42") {
+		t.Errorf("Expected Clear to leave the rest of the cache alone, got keys %v", sut.Keys())
+	}
+}
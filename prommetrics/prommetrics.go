@@ -0,0 +1,63 @@
+// Package prommetrics exports the statistics of a keyvalstore cache as
+// Prometheus metrics. It lives in its own module so that only programs that
+// use it depend on the Prometheus client.
+package prommetrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	keyvalstore "github.com/peeperklip/simplecache"
+)
+
+// Register registers a collector for c with registerer. The collector exports
+// name_hits_total, name_misses_total, name_evictions_total and
+// name_expirations_total counters and a name_inflight_loads gauge from c.Stats,
+// and a name_entries gauge read from c.Len, all taken at scrape time. name must
+// be a valid Prometheus metric name prefix and unique within registerer.
+func Register[K comparable, V any](registerer prometheus.Registerer, name string, c *keyvalstore.Cache[K, V]) error {
+	return registerer.Register(newCollector(name, c))
+}
+
+// collector reads a cache's statistics whenever it is scraped.
+type collector[K comparable, V any] struct {
+	cache       *keyvalstore.Cache[K, V]
+	hits        *prometheus.Desc
+	misses      *prometheus.Desc
+	evictions   *prometheus.Desc
+	expirations *prometheus.Desc
+	inFlight    *prometheus.Desc
+	entries     *prometheus.Desc
+}
+
+func newCollector[K comparable, V any](name string, c *keyvalstore.Cache[K, V]) *collector[K, V] {
+	return &collector[K, V]{
+		cache:       c,
+		hits:        prometheus.NewDesc(name+"_hits_total", "Number of cache lookups that found a live entry.", nil, nil),
+		misses:      prometheus.NewDesc(name+"_misses_total", "Number of cache lookups for absent or expired keys.", nil, nil),
+		evictions:   prometheus.NewDesc(name+"_evictions_total", "Number of entries evicted by a capacity limit.", nil, nil),
+		expirations: prometheus.NewDesc(name+"_expirations_total", "Number of expired entries removed from the cache.", nil, nil),
+		inFlight:    prometheus.NewDesc(name+"_inflight_loads", "Number of GetOrLoad loaders currently running.", nil, nil),
+		entries:     prometheus.NewDesc(name+"_entries", "Number of live entries in the cache.", nil, nil),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *collector[K, V]) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.evictions
+	ch <- c.expirations
+	ch <- c.inFlight
+	ch <- c.entries
+}
+
+// Collect implements prometheus.Collector.
+func (c *collector[K, V]) Collect(ch chan<- prometheus.Metric) {
+	stats := c.cache.Stats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(stats.Evictions))
+	ch <- prometheus.MustNewConstMetric(c.expirations, prometheus.CounterValue, float64(stats.Expirations))
+	ch <- prometheus.MustNewConstMetric(c.inFlight, prometheus.GaugeValue, float64(stats.InFlightLoads))
+	ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(c.cache.Len()))
+}
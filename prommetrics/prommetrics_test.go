@@ -0,0 +1,66 @@
+package prommetrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	keyvalstore "github.com/peeperklip/simplecache"
+)
+
+func TestRegister(t *testing.T) {
+	cache := keyvalstore.NewSimpleCache[string](0)
+	defer cache.Close()
+	registry := prometheus.NewRegistry()
+	if err := Register(registry, "sessions", cache); err != nil {
+		t.Fatalf("Expected registration to succeed, got %v", err)
+	}
+
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+	cache.SetWithTTL("expired", "value3", -time.Second)
+	cache.Get("key1")
+	cache.Get("missing")
+	cache.Get("expired")
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Expected gathering to succeed, got %v", err)
+	}
+	got := make(map[string]float64)
+	for _, f := range families {
+		m := f.GetMetric()[0]
+		if c := m.GetCounter(); c != nil {
+			got[f.GetName()] = c.GetValue()
+		} else {
+			got[f.GetName()] = m.GetGauge().GetValue()
+		}
+	}
+	want := map[string]float64{
+		"sessions_hits_total":        1,
+		"sessions_misses_total":      2,
+		"sessions_evictions_total":   0,
+		"sessions_expirations_total": 1,
+		"sessions_inflight_loads":    0,
+		"sessions_entries":           2,
+	}
+	for name, v := range want {
+		if got[name] != v {
+			t.Errorf("Expected %s to be %v, got %v", name, v, got[name])
+		}
+	}
+}
+
+func TestRegisterDuplicateName(t *testing.T) {
+	cache := keyvalstore.NewSimpleCache[string](0)
+	defer cache.Close()
+	registry := prometheus.NewRegistry()
+
+	if err := Register(registry, "sessions", cache); err != nil {
+		t.Fatalf("Expected registration to succeed, got %v", err)
+	}
+	if err := Register(registry, "sessions", cache); err == nil {
+		t.Errorf("Expected registering the same name twice to fail")
+	}
+}
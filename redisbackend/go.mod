@@ -0,0 +1,14 @@
+module github.com/peeperklip/simplecache/redisbackend
+
+go 1.25.3
+
+require (
+	github.com/peeperklip/simplecache v0.0.0-20261014074651-54d6210404fb
+	github.com/redis/go-redis/v9 v9.22.0
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	go.uber.org/atomic v1.11.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+)
@@ -0,0 +1,107 @@
+// Package redisbackend provides a keyvalstore.Backend that stores values in
+// Redis, for use as the second tier of a keyvalstore.TieredCache. It lives in
+// its own module so that only programs that use it depend on the Redis client.
+package redisbackend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	keyvalstore "github.com/peeperklip/simplecache"
+)
+
+// Client is the subset of a Redis client used by Backend. It is satisfied by
+// *redis.Client, *redis.ClusterClient and *redis.Ring.
+type Client interface {
+	Get(ctx context.Context, key string) *redis.StringCmd
+	Set(ctx context.Context, key string, value any, expiration time.Duration) *redis.StatusCmd
+	Del(ctx context.Context, keys ...string) *redis.IntCmd
+}
+
+// Codec converts values to and from the bytes stored in Redis.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodec is the default Codec, using encoding/json.
+type JSONCodec struct{}
+
+// Marshal implements Codec.
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal implements Codec.
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// Backend is a keyvalstore.Backend storing values of type T in Redis.
+type Backend[T any] struct {
+	client Client
+	codec  Codec
+}
+
+var _ keyvalstore.Backend[string] = (*Backend[string])(nil)
+
+// Option configures a Backend.
+type Option func(*options)
+
+type options struct {
+	codec Codec
+}
+
+// WithCodec sets the codec used to serialize values, which defaults to
+// JSONCodec. A nil codec is ignored.
+func WithCodec(codec Codec) Option {
+	return func(o *options) {
+		if codec != nil {
+			o.codec = codec
+		}
+	}
+}
+
+// New creates a Backend that stores values through client.
+func New[T any](client Client, opts ...Option) *Backend[T] {
+	o := options{codec: JSONCodec{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Backend[T]{client: client, codec: o.codec}
+}
+
+// Get implements keyvalstore.Backend. A key missing from Redis is reported as
+// not found with a nil error.
+func (b *Backend[T]) Get(key string) (T, bool, error) {
+	var value T
+	data, err := b.client.Get(context.Background(), key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return value, false, nil
+	}
+	if err != nil {
+		return value, false, err
+	}
+	if err := b.codec.Unmarshal(data, &value); err != nil {
+		return value, false, err
+	}
+	return value, true, nil
+}
+
+// Set implements keyvalstore.Backend, setting the Redis key to expire after
+// ttl. A non-positive ttl means the value is already expired, as it does for
+// keyvalstore.Cache.SetWithTTL, so the Redis key is deleted instead.
+func (b *Backend[T]) Set(key string, value T, ttl time.Duration) error {
+	if ttl <= 0 {
+		return b.client.Del(context.Background(), key).Err()
+	}
+	data, err := b.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return b.client.Set(context.Background(), key, data, ttl).Err()
+}
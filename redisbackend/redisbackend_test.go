@@ -0,0 +1,176 @@
+package redisbackend
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	keyvalstore "github.com/peeperklip/simplecache"
+)
+
+// fakeClient is an in-memory Client recording the expiry of each key.
+type fakeClient struct {
+	data    map[string]string
+	expires map[string]time.Duration
+	err     error
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{data: make(map[string]string), expires: make(map[string]time.Duration)}
+}
+
+func (f *fakeClient) Get(ctx context.Context, key string) *redis.StringCmd {
+	if f.err != nil {
+		return redis.NewStringResult("", f.err)
+	}
+	v, ok := f.data[key]
+	if !ok {
+		return redis.NewStringResult("", redis.Nil)
+	}
+	return redis.NewStringResult(v, nil)
+}
+
+func (f *fakeClient) Set(ctx context.Context, key string, value any, expiration time.Duration) *redis.StatusCmd {
+	if f.err != nil {
+		return redis.NewStatusResult("", f.err)
+	}
+	f.data[key] = string(value.([]byte))
+	f.expires[key] = expiration
+	return redis.NewStatusResult("OK", nil)
+}
+
+func (f *fakeClient) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	if f.err != nil {
+		return redis.NewIntResult(0, f.err)
+	}
+	var n int64
+	for _, key := range keys {
+		if _, ok := f.data[key]; ok {
+			delete(f.data, key)
+			delete(f.expires, key)
+			n++
+		}
+	}
+	return redis.NewIntResult(n, nil)
+}
+
+type user struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func TestBackend_SetAndGet(t *testing.T) {
+	client := newFakeClient()
+	sut := New[user](client)
+
+	if err := sut.Set("user:1", user{Name: "Ada", Age: 36}, time.Minute); err != nil {
+		t.Fatalf("Expected Set to succeed, got %v", err)
+	}
+	if client.data["user:1"] != `{"name":"Ada","age":36}` {
+		t.Errorf("Expected the value to be stored as JSON, got %s", client.data["user:1"])
+	}
+	if client.expires["user:1"] != time.Minute {
+		t.Errorf("Expected the Redis key to expire after 1m, got %v", client.expires["user:1"])
+	}
+
+	val, found, err := sut.Get("user:1")
+	if err != nil || !found || val.Name != "Ada" || val.Age != 36 {
+		t.Errorf("Expected to find user:1, got %+v, found: %v, err: %v", val, found, err)
+	}
+}
+
+func TestBackend_GetMissing(t *testing.T) {
+	sut := New[user](newFakeClient())
+
+	if _, found, err := sut.Get("user:2"); found || err != nil {
+		t.Errorf("Expected redis.Nil to be reported as a miss, got found: %v, err: %v", found, err)
+	}
+}
+
+func TestBackend_Errors(t *testing.T) {
+	client := newFakeClient()
+	client.err = errors.New("connection refused")
+	sut := New[user](client)
+
+	if _, _, err := sut.Get("user:1"); !errors.Is(err, client.err) {
+		t.Errorf("Expected the client error from Get, got %v", err)
+	}
+	if err := sut.Set("user:1", user{}, time.Minute); !errors.Is(err, client.err) {
+		t.Errorf("Expected the client error from Set, got %v", err)
+	}
+
+	client.err = nil
+	client.data["user:1"] = "not json"
+	if _, found, err := sut.Get("user:1"); err == nil || found {
+		t.Errorf("Expected a decoding error, got found: %v, err: %v", found, err)
+	}
+}
+
+func TestBackend_NonPositiveTTLDeletes(t *testing.T) {
+	client := newFakeClient()
+	sut := New[user](client)
+
+	sut.Set("user:1", user{Name: "Ada"}, time.Minute)
+	if err := sut.Set("user:1", user{}, -time.Second); err != nil {
+		t.Fatalf("Expected Set to succeed, got %v", err)
+	}
+	if _, ok := client.data["user:1"]; ok {
+		t.Errorf("Expected a non-positive TTL to delete the Redis key")
+	}
+}
+
+// prefixCodec stores strings behind an "X" marker, to check WithCodec is honoured.
+type prefixCodec struct{}
+
+func (prefixCodec) Marshal(v any) ([]byte, error) { return []byte("X" + v.(string)), nil }
+
+func (prefixCodec) Unmarshal(data []byte, v any) error {
+	*v.(*string) = string(data[1:])
+	return nil
+}
+
+func TestBackend_WithCodec(t *testing.T) {
+	client := newFakeClient()
+	sut := New[string](client, WithCodec(prefixCodec{}))
+
+	sut.Set("key1", "value1", time.Minute)
+	if client.data["key1"] != "Xvalue1" {
+		t.Errorf("Expected the custom codec to encode the value, got %s", client.data["key1"])
+	}
+	if val, _, _ := sut.Get("key1"); val != "value1" {
+		t.Errorf("Expected the custom codec to decode the value, got %s", val)
+	}
+}
+
+func TestBackend_InTieredCache(t *testing.T) {
+	client := newFakeClient()
+	client.data["greeting"] = `"hello"`
+	l1 := keyvalstore.NewSimpleCache[string](0)
+	defer l1.Close()
+	sut := keyvalstore.NewTieredCache[string](l1, New[string](client), time.Minute)
+
+	if val, found, err := sut.Get("greeting"); err != nil || !found || val != "hello" {
+		t.Errorf("Expected to read greeting through Redis, got '%s', found: %v, err: %v", val, found, err)
+	}
+	if !l1.Has("greeting") {
+		t.Errorf("Expected the value read from Redis to populate l1")
+	}
+}
+
+func TestBackend_InTieredCacheNonPositiveTTL(t *testing.T) {
+	client := newFakeClient()
+	l1 := keyvalstore.NewSimpleCache[string](0)
+	defer l1.Close()
+	sut := keyvalstore.NewTieredCache[string](l1, New[string](client), time.Minute)
+
+	sut.Set("greeting", "hello", time.Minute)
+	if err := sut.Set("greeting", "stale", 0); err != nil {
+		t.Fatalf("Expected Set to succeed, got %v", err)
+	}
+	if val, found, err := sut.Get("greeting"); found || err != nil {
+		t.Errorf("Expected a value set with a zero TTL to be expired in both tiers, got '%s', found: %v, err: %v", val, found, err)
+	}
+}
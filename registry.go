@@ -0,0 +1,64 @@
+package keyvalstore
+
+import (
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Closer is implemented by anything a Registry can shut down, including every
+// Cache regardless of its key and value types.
+type Closer interface {
+	Close()
+}
+
+// Registry tracks named caches so they can be shut down together, e.g. on
+// graceful exit. Because caches of different types cannot share a single
+// generic container, it stores them as Closers; callers that need the typed
+// cache back can assert it, as in r.Get("users").(*SimpleCache[User]). A
+// Registry is safe for concurrent use.
+type Registry struct {
+	mutex  sync.Mutex
+	caches map[string]Closer
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{caches: make(map[string]Closer)}
+}
+
+// Register adds c under name. A cache already registered under name is
+// replaced without being closed.
+func (r *Registry) Register(name string, c Closer) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.caches[name] = c
+}
+
+// Get returns the cache registered under name and whether there was one.
+func (r *Registry) Get(name string) (Closer, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	c, ok := r.caches[name]
+	return c, ok
+}
+
+// Names returns the names of the registered caches in sorted order.
+func (r *Registry) Names() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return slices.Sorted(maps.Keys(r.caches))
+}
+
+// CloseAll closes every registered cache and empties the registry. Caches
+// are closed one at a time, in name order.
+func (r *Registry) CloseAll() {
+	r.mutex.Lock()
+	caches := r.caches
+	r.caches = make(map[string]Closer)
+	r.mutex.Unlock()
+
+	for _, name := range slices.Sorted(maps.Keys(caches)) {
+		caches[name].Close()
+	}
+}
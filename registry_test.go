@@ -0,0 +1,61 @@
+package keyvalstore
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// Every cache must be usable as a Closer, whatever its type parameters.
+var (
+	_ Closer = (*SimpleCache[string])(nil)
+	_ Closer = (*Cache[int, []byte])(nil)
+)
+
+func TestRegistry_RegisterAndGet(t *testing.T) {
+	sut := NewRegistry()
+	users := NewSimpleCache[string](0)
+	sut.Register("users", users)
+	sut.Register("counters", NewCache[int, int](WithCleanupInterval[int](0)))
+
+	c, found := sut.Get("users")
+	if !found {
+		t.Fatalf("Expected users to be registered")
+	}
+	if typed, ok := c.(*SimpleCache[string]); !ok || typed != users {
+		t.Errorf("Expected Get to return the registered cache, got %v", c)
+	}
+	if _, found = sut.Get("missing"); found {
+		t.Errorf("Expected no cache under an unregistered name")
+	}
+	if names := sut.Names(); !slices.Equal(names, []string{"counters", "users"}) {
+		t.Errorf("Expected sorted names [counters users], got %v", names)
+	}
+}
+
+func TestRegistry_CloseAll(t *testing.T) {
+	sut := NewRegistry()
+	first := NewSimpleCache[string](time.Millisecond)
+	second := NewCache[int, string]()
+	sut.Register("first", first)
+	sut.Register("second", second)
+
+	sut.CloseAll()
+	if !isClosed(first) || !isClosed(second) {
+		t.Errorf("Expected CloseAll to close every registered cache")
+	}
+	if names := sut.Names(); len(names) != 0 {
+		t.Errorf("Expected CloseAll to empty the registry, got %v", names)
+	}
+	sut.CloseAll() // closing an empty registry is a no-op
+}
+
+// isClosed reports whether Close has been called on c.
+func isClosed[K comparable, V any](c *Cache[K, V]) bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,152 @@
+package keyvalstore
+
+import (
+	"hash/maphash"
+	"sync"
+)
+
+// shard is an independently locked partition of a cache's entries. Each shard
+// has its own expiry queue, eviction policy and share of the capacity limits.
+type shard[K comparable, V any] struct {
+	mutex      sync.RWMutex
+	data       map[K]cacheItem[V]
+	expiries   *expiryQueue[K]
+	policy     evictionPolicy[K]
+	maxEntries int
+	maxCost    int64
+	totalCost  int64
+	maxBytes   int64
+	totalBytes int64
+	// tags indexes the keys stored with each tag. It is nil until an item
+	// with tags is stored.
+	tags map[string]map[K]struct{}
+	// waiters holds the channels of WaitForKey callers waiting for each key
+	// to be stored. It is nil until a caller waits.
+	waiters map[K][]chan struct{}
+}
+
+// newShard creates the empty shard at index i with an even share of the
+// cache's limits.
+func (c *Cache[K, V]) newShard(i int) *shard[K, V] {
+	s := &shard[K, V]{
+		data:     make(map[K]cacheItem[V], ceilDiv(c.initialCapacity, c.shardCount)),
+		expiries: newExpiryQueue[K](),
+	}
+	if c.policy != noPolicy {
+		s.policy = newPolicy[K](c.policy)
+		s.maxEntries = shareOf(c.maxEntries, c.shardCount, i)
+		s.maxCost = shareOf(c.maxCost, c.shardCount, i)
+		s.maxBytes = shareOf(c.maxBytes, c.shardCount, i)
+	}
+	return s
+}
+
+// shareOf returns the part of limit given to the shard at index i of shards,
+// or 0 if limit is not positive. The shares add up to limit, except that each
+// shard gets at least 1 when limit is smaller than the number of shards.
+func shareOf[N int | int64](limit N, shards, i int) N {
+	if limit <= 0 {
+		return 0
+	}
+	share := limit / N(shards)
+	if N(i) < limit%N(shards) {
+		share++
+	}
+	return max(share, 1)
+}
+
+func ceilDiv[N int | int64](a, b N) N {
+	return (a + b - 1) / b
+}
+
+// shardFor returns the shard that owns key.
+func (c *Cache[K, V]) shardFor(key K) *shard[K, V] {
+	if len(c.shards) == 1 {
+		return c.shards[0]
+	}
+	return c.shards[c.shardIndex(key)]
+}
+
+func (c *Cache[K, V]) shardIndex(key K) int {
+	return int(maphash.Comparable(c.seed, key) % uint64(len(c.shards)))
+}
+
+// groupByShard partitions keys by the index of the shard that owns them.
+func (c *Cache[K, V]) groupByShard(keys []K) [][]K {
+	if len(c.shards) == 1 {
+		return [][]K{keys}
+	}
+	groups := make([][]K, len(c.shards))
+	for _, key := range keys {
+		i := c.shardIndex(key)
+		groups[i] = append(groups[i], key)
+	}
+	return groups
+}
+
+// lockAll write-locks every shard. Shards are always locked in index order,
+// so operations spanning several shards cannot deadlock each other.
+func (c *Cache[K, V]) lockAll() {
+	for _, s := range c.shards {
+		s.mutex.Lock()
+	}
+}
+
+func (c *Cache[K, V]) unlockAll() {
+	for _, s := range c.shards {
+		s.mutex.Unlock()
+	}
+}
+
+// rlockAll read-locks every shard in index order.
+func (c *Cache[K, V]) rlockAll() {
+	for _, s := range c.shards {
+		s.mutex.RLock()
+	}
+}
+
+func (c *Cache[K, V]) runlockAll() {
+	for _, s := range c.shards {
+		s.mutex.RUnlock()
+	}
+}
+
+// storedLocked returns the number of stored items, live or expired, across all
+// shards. The caller must hold every shard's lock.
+func (c *Cache[K, V]) storedLocked() int {
+	n := 0
+	for _, s := range c.shards {
+		n += len(s.data)
+	}
+	return n
+}
+
+// removeLocked deletes key from the shard and its eviction bookkeeping,
+// returning the removed item. The caller must hold the write lock.
+func (s *shard[K, V]) removeLocked(key K) (cacheItem[V], bool) {
+	item, exists := s.data[key]
+	if !exists {
+		return item, false
+	}
+	if s.policy != nil {
+		s.policy.remove(key)
+	}
+	s.expiries.remove(key)
+	s.untagLocked(key, item.tags)
+	s.totalCost -= item.cost
+	s.totalBytes -= item.size
+	delete(s.data, key)
+	return item, true
+}
+
+// reset removes all entries. The caller must hold the write lock.
+func (s *shard[K, V]) reset() {
+	s.data = make(map[K]cacheItem[V])
+	s.expiries.reset()
+	s.tags = nil
+	if s.policy != nil {
+		s.policy.reset()
+	}
+	s.totalCost = 0
+	s.totalBytes = 0
+}
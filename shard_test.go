@@ -0,0 +1,171 @@
+package keyvalstore
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSimpleCache_Sharded(t *testing.T) {
+	sut := NewSimpleCache(1*time.Minute, WithShards[int](8))
+	defer sut.Close()
+
+	const numKeys = 100
+	for i := 0; i < numKeys; i++ {
+		sut.Set(fmt.Sprintf("key%d", i), i)
+	}
+
+	if n := sut.Len(); n != numKeys {
+		t.Errorf("Expected %d entries across shards, got %d", numKeys, n)
+	}
+	keys := sut.Keys()
+	sort.Strings(keys)
+	if len(keys) != numKeys || keys[0] != "key0" {
+		t.Errorf("Expected %d keys starting with key0, got %d keys starting with %v", numKeys, len(keys), keys[:1])
+	}
+	for i := 0; i < numKeys; i++ {
+		if val, found := sut.Get(fmt.Sprintf("key%d", i)); !found || val != i {
+			t.Errorf("Expected key%d to be %d, got %d, found: %v", i, i, val, found)
+		}
+	}
+
+	got := sut.GetMany([]string{"key1", "key50", "missing"})
+	if len(got) != 2 || got["key1"] != 1 || got["key50"] != 50 {
+		t.Errorf("Expected GetMany to gather keys across shards, got %v", got)
+	}
+
+	sut.DeleteMany([]string{"key1", "key50"})
+	sut.Clear()
+	if n := sut.Len(); n != 0 {
+		t.Errorf("Expected Clear to empty every shard, got %d entries", n)
+	}
+}
+
+func TestSimpleCache_ShardedCapacityIsSplitBetweenShards(t *testing.T) {
+	sut := NewSimpleCache(1*time.Minute, WithShards[int](4), WithMaxEntries[int](40))
+	defer sut.Close()
+
+	for i := 0; i < 1000; i++ {
+		sut.Set(fmt.Sprintf("key%d", i), i)
+	}
+	for i, s := range sut.shards {
+		if s.maxEntries != 10 || len(s.data) > 10 {
+			t.Errorf("Expected shard %d to hold at most 10 entries, holds %d with a limit of %d", i, len(s.data), s.maxEntries)
+		}
+	}
+}
+
+func TestSimpleCache_ShardedMaxCost(t *testing.T) {
+	sut := NewSimpleCache(0, WithShards[int64](4), WithMaxCost(100, func(v int64) int64 { return v }))
+
+	for i := range 4 {
+		if sut.Set(fmt.Sprintf("key%d", i), 80) {
+			t.Errorf("Expected a value above its shard's share of the cost limit to be rejected")
+		}
+	}
+	for i := range 100 {
+		sut.Set(fmt.Sprintf("key%d", i), 20)
+	}
+	var total int64
+	for _, s := range sut.shards {
+		total += s.totalCost
+	}
+	if total > 100 {
+		t.Errorf("Expected the shards to hold a total cost of at most 100, got %d", total)
+	}
+}
+
+func TestSimpleCache_ShardedMaxBytes(t *testing.T) {
+	sut := NewSimpleCache(0, WithShards[int64](4), WithMaxBytes[int64](400), WithSizeEstimator(func(v int64) int64 { return v }))
+
+	for i := range 4 {
+		if sut.Set(fmt.Sprintf("key%d", i), 90) {
+			t.Errorf("Expected a value above its shard's share of the byte limit to be rejected")
+		}
+	}
+	for i := range 100 {
+		sut.Set(fmt.Sprintf("key%d", i), 10)
+	}
+	var total int64
+	for _, s := range sut.shards {
+		total += s.totalBytes
+	}
+	if total > 400 {
+		t.Errorf("Expected the shards to hold at most 400 bytes, got %d", total)
+	}
+}
+
+func TestSimpleCache_ShardedMaxEntriesAddsUp(t *testing.T) {
+	sut := NewSimpleCache(0, WithShards[int](4), WithMaxEntries[int](10))
+
+	for i := range 1000 {
+		sut.Set(fmt.Sprintf("key%d", i), i)
+	}
+	if n := sut.Len(); n != 10 {
+		t.Errorf("Expected the shards' limits to add up to 10 entries, got %d", n)
+	}
+	sut.Resize(6)
+	if n := sut.Len(); n != 6 {
+		t.Errorf("Expected Resize to split the new limit exactly, got %d entries", n)
+	}
+}
+
+func TestSimpleCache_ShardedConcurrentAccess(t *testing.T) {
+	sut := NewSimpleCache(1*time.Millisecond, WithShards[int](8))
+	defer sut.Close()
+	const numGoroutines = 20
+	const numIterations = 500
+
+	var wg sync.WaitGroup
+	wg.Add(numGoroutines)
+	for i := 0; i < numGoroutines; i++ {
+		go func(id int) {
+			defer wg.Done()
+			for j := 0; j < numIterations; j++ {
+				key := fmt.Sprintf("key%d", j%50)
+				sut.SetWithTTL(key, id*j, time.Millisecond)
+				sut.Get(key)
+				if j%100 == 0 {
+					sut.Len()
+					sut.Keys()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func benchmarkParallelMixed(b *testing.B, c *SimpleCache[int]) {
+	const numKeys = 1024
+	keys := make([]string, numKeys)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+		c.Set(keys[i], i)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := keys[i%numKeys]
+			if i%4 == 0 {
+				c.Set(key, i)
+			} else {
+				c.Get(key)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkParallelSingleLock(b *testing.B) {
+	c := NewSimpleCache(0, WithMaxEntries[int](4096))
+	benchmarkParallelMixed(b, c)
+}
+
+func BenchmarkParallelSharded(b *testing.B) {
+	c := NewSimpleCache(0, WithMaxEntries[int](4096), WithShards[int](32))
+	benchmarkParallelMixed(b, c)
+}
@@ -1,95 +1,1109 @@
 package keyvalstore
 
 import (
+	"context"
+	"fmt"
+	"hash/maphash"
+	"math"
+	"math/rand/v2"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-// SimpleCache is a thread-safe in-memory key-value store with expiration.
-type SimpleCache[T any] struct {
-	data            map[string]cacheItem[T]
-	cleanupInterval time.Duration
+// NoExpiration is the remaining lifetime TTL reports for items that never expire.
+const NoExpiration time.Duration = -1
 
-	mutex     sync.RWMutex
-	done      chan struct{}
-	wg        sync.WaitGroup
+// Cache is a thread-safe in-memory key-value store with expiration, for any
+// comparable key type K and value type V.
+type Cache[K comparable, V any] struct {
+	// config holds the settings applied by options.
+	config[V]
+
+	// shards partition the entries by key hash, each with its own lock.
+	// There is a single shard unless WithShards asks for more.
+	shards []*shard[K, V]
+	seed   maphash.Seed
+
+	onEvicted       atomic.Pointer[func(key K, value V, reason EvictionReason)]
+	subscribers     subscribers[K, V]
+	subscriberCount atomic.Int32
+	stats           stats
+
+	loadMutex sync.Mutex
+	loads     map[K]*loadCall[V]
+	// loader is the read-through loader set by WithLoader, or nil.
+	loader func(key K) (V, time.Duration, error)
+	// onMiss is the hook set by WithOnMiss, or nil.
+	onMiss func(key K)
+	// onBatchEvicted is the handler set by WithBatchEvictionHandler, or nil.
+	onBatchEvicted func(items map[K]V, reason EvictionReason)
+	// loadSlots holds a token for each running load when the number of
+	// concurrent loads is limited, and is nil otherwise.
+	loadSlots chan struct{}
+	// breaker is the loader circuit breaker, or nil if it is disabled.
+	breaker *circuitBreaker
+
+	onceMutex sync.Mutex
+	onces     map[K]*onceValue[V]
+
+	// janitorMutex guards cleanupInterval, janitorRunning and, once the cache
+	// is created, maxEntries, and orders starting the janitor against Close.
+	// intervalChanged wakes the janitor to pick up a new cleanupInterval.
+	janitorMutex    sync.Mutex
+	janitorRunning  bool
+	intervalChanged chan struct{}
+	// interval is the janitor's current interval, or zero if it is stopped.
+	interval atomic.Int64
+
+	// paused makes the janitor skip its ticks; see PauseCleanup.
+	paused atomic.Bool
+	done   chan struct{}
+	wg     sync.WaitGroup
+	// closed is set by Close, after which writes are ignored.
+	closed    atomic.Bool
 	closeOnce sync.Once
+	flushOnce sync.Once
 }
 
-type cacheItem[T any] struct {
-	value      T
+// SimpleCache is a Cache with string keys, the original form of this package's
+// cache. All of Cache's methods are available on it.
+type SimpleCache[T any] = Cache[string, T]
+
+type cacheItem[V any] struct {
+	value      V
 	expiryTime time.Time
+	// ttl is the lifetime the item was stored with, used to slide expiryTime.
+	ttl time.Duration
+	// cost is the item's weight against maxCost, if a cost function is set.
+	cost int64
+	// size is the estimated memory held by the item and its key, if the cache
+	// has a byte limit.
+	size int64
+	// negative marks a cached loader miss, which reads report as absent.
+	negative bool
+	// tags are the tags the item was stored with by SetWithTags.
+	tags []string
+	// meta is the metadata the item was stored with by SetWithMeta.
+	meta map[string]string
+	// version is the version the item was stored with by SetIfNewer.
+	version int64
+	// hits counts the Gets that found the item, for WithSegmentedTTL.
+	hits uint32
 }
 
-// NewSimpleCache creates a new SimpleCache with a specified cleanup interval.
-func NewSimpleCache[T any](cleanupInterval time.Duration) *SimpleCache[T] {
-	c := &SimpleCache[T]{
-		data:            make(map[string]cacheItem[T]),
+// expired reports whether the item has expired at the given time.
+// Items with a zero expiryTime never expire.
+func (it cacheItem[V]) expired(now time.Time) bool {
+	return !it.expiryTime.IsZero() && now.After(it.expiryTime)
+}
+
+// live reports whether the item holds a value that reads may return at the
+// given time: it has not expired and is not a cached loader miss.
+func (it cacheItem[V]) live(now time.Time) bool {
+	return !it.negative && !it.expired(now)
+}
+
+// expiryAt returns when an item stored at now with the given ttl expires. With
+// WithJitter, a positive ttl is randomly lengthened or shortened by up to the
+// configured fraction.
+func (c *Cache[K, V]) expiryAt(now time.Time, ttl time.Duration) time.Time {
+	if c.jitter > 0 && ttl > 0 {
+		ttl += time.Duration((2*rand.Float64() - 1) * c.jitter * float64(ttl))
+	}
+	return now.Add(ttl)
+}
+
+// DefaultCleanupInterval is how often the janitor of a cache created with New
+// removes expired items, unless WithCleanupInterval sets another interval.
+const DefaultCleanupInterval = time.Minute
+
+// NewCache creates a Cache with keys of type K, configured by opts. Options
+// left out keep their defaults: the janitor runs every DefaultCleanupInterval,
+// items stored with Set never expire, the cache is unbounded with a single
+// shard, and expiry is measured with the system clock. Options given invalid
+// values, such as a negative entry limit, are ignored. Call Close to stop the
+// janitor once the cache is no longer needed.
+func NewCache[K comparable, V any](opts ...Option[V]) *Cache[K, V] {
+	cfg := defaultConfig[V]()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return newCache[K](cfg, maphash.MakeSeed())
+}
+
+// newCache creates an empty cache with the given configuration and shard seed,
+// starting its janitor if cfg has a positive cleanup interval.
+func newCache[K comparable, V any](cfg config[V], seed maphash.Seed) *Cache[K, V] {
+	c := &Cache[K, V]{
+		config:          cfg,
+		seed:            seed,
 		done:            make(chan struct{}),
-		cleanupInterval: cleanupInterval,
+		intervalChanged: make(chan struct{}, 1),
+	}
+	c.shards = make([]*shard[K, V], c.shardCount)
+	for i := range c.shards {
+		c.shards[i] = c.newShard(i)
+	}
+	if c.maxConcurrentLoads > 0 {
+		c.loadSlots = make(chan struct{}, c.maxConcurrentLoads)
+	}
+	if c.readThrough != nil {
+		loader, ok := c.readThrough.(func(K) (V, time.Duration, error))
+		if !ok {
+			panic(fmt.Sprintf("keyvalstore: WithLoader loader %T does not match the cache's key type %T", c.readThrough, *new(K)))
+		}
+		c.loader = loader
+	}
+	if c.missHook != nil {
+		onMiss, ok := c.missHook.(func(K))
+		if !ok {
+			panic(fmt.Sprintf("keyvalstore: WithOnMiss hook %T does not match the cache's key type %T", c.missHook, *new(K)))
+		}
+		c.onMiss = onMiss
+	}
+	if c.batchHandler != nil {
+		handler, ok := c.batchHandler.(func(map[K]V, EvictionReason))
+		if !ok {
+			panic(fmt.Sprintf("keyvalstore: WithBatchEvictionHandler handler %T does not match the cache's key type %T", c.batchHandler, *new(K)))
+		}
+		c.onBatchEvicted = handler
+	}
+	if c.breakerThreshold > 0 {
+		c.breaker = &circuitBreaker{threshold: c.breakerThreshold, window: c.breakerWindow, cooldown: c.breakerCooldown}
 	}
 
-	c.wg.Add(1)
-	go c.janitor()
+	if c.cleanupInterval > 0 {
+		c.startJanitorLocked()
+	}
 	return c
 }
 
-// Set adds a key-value pair to the cache with an expiration time.
-func (c *SimpleCache[T]) Set(key string, expiryDur time.Duration, value T) {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
-	c.data[key] = cacheItem[T]{
-		value:      value,
-		expiryTime: time.Now().Add(expiryDur),
+// New creates a SimpleCache, with string keys, configured by opts as described
+// for NewCache.
+func New[T any](opts ...Option[T]) *SimpleCache[T] {
+	return NewCache[string](opts...)
+}
+
+// NewSimpleCache creates a new SimpleCache with a specified cleanup interval.
+// A non-positive interval disables the background janitor; expired items are
+// then never served, and are removed when Get finds them or when they are
+// overwritten or deleted. It is equivalent to New with WithCleanupInterval
+// followed by opts.
+func NewSimpleCache[T any](cleanupInterval time.Duration, opts ...Option[T]) *SimpleCache[T] {
+	return New(append([]Option[T]{WithCleanupInterval[T](cleanupInterval)}, opts...)...)
+}
+
+// Set adds a key-value pair to the cache using the cache's default TTL.
+// If no default TTL was configured (see WithDefaultTTL), the item never expires.
+// It returns false, leaving the cache unchanged, if the value alone exceeds the
+// cache's maximum cost or size (see WithMaxCost and WithMaxBytes), or if the
+// cache is closed (see Close).
+func (c *Cache[K, V]) Set(key K, value V) bool {
+	var expiryTime time.Time
+	if c.defaultTTL > 0 {
+		expiryTime = c.expiryAt(c.clock.Now(), c.defaultTTL)
+	}
+	return c.set(key, value, c.defaultTTL, expiryTime) == nil
+}
+
+// SetWithTTL adds a key-value pair to the cache with an expiration time.
+// The item expires ttl after the call; a non-positive ttl stores an item that
+// is already expired and will never be returned by Get. The result is as for Set.
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) bool {
+	return c.set(key, value, ttl, c.expiryAt(c.clock.Now(), ttl)) == nil
+}
+
+// SetAt adds a key-value pair to the cache that expires at expireAt, e.g. the
+// expiry of a token, without converting it to a TTL first. WithJitter does not
+// apply. As with a non-positive ttl for SetWithTTL, an expireAt that is not in
+// the future stores an item that is already expired and will never be returned
+// by Get, replacing any previous value. A zero expireAt stores an item that
+// never expires, as SetForever does. The result is as for Set.
+func (c *Cache[K, V]) SetAt(key K, value V, expireAt time.Time) bool {
+	if expireAt.IsZero() {
+		return c.SetForever(key, value)
+	}
+	return c.set(key, value, expireAt.Sub(c.clock.Now()), expireAt) == nil
+}
+
+// SetForever adds a key-value pair to the cache that never expires,
+// regardless of the default TTL. The janitor never removes such items.
+// The result is as for Set.
+func (c *Cache[K, V]) SetForever(key K, value V) bool {
+	return c.set(key, value, 0, time.Time{}) == nil
+}
+
+// Store adds a key-value pair to the cache with an expiration time, as
+// SetWithTTL does, but returns ErrItemTooLarge instead of false if the value
+// is rejected for exceeding the cache's maximum cost or size, and ErrClosed if
+// the cache is closed. If the cost function, size estimator or copier panics
+// and WithPanicHandler recovers it, the value is not stored and Store returns
+// a non-nil error.
+func (c *Cache[K, V]) Store(key K, value V, ttl time.Duration) error {
+	return c.set(key, value, ttl, c.expiryAt(c.clock.Now(), ttl))
+}
+
+// set stores value under key, returning the error from newItem if the value
+// is not admitted.
+func (c *Cache[K, V]) set(key K, value V, ttl time.Duration, expiryTime time.Time) error {
+	item, err := c.newItem(key, value, ttl, expiryTime)
+	if err != nil {
+		return err
+	}
+
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	evicted := c.storeLocked(s, key, item)
+	s.mutex.Unlock()
+
+	c.notifyEvicted(evicted)
+	return nil
+}
+
+// TrySet stores value under key with the given ttl, as SetWithTTL does, and
+// reports whether a capacity limit evicted another entry to make room, and
+// which. If several entries were evicted, as can happen under a cost or size
+// limit, the first one is returned. A value rejected for exceeding the cache's
+// maximum cost or size is not stored and evicts nothing, so TrySet then
+// returns the zero key and false; use SetWithTTL to detect rejection.
+func (c *Cache[K, V]) TrySet(key K, value V, ttl time.Duration) (evictedKey K, evicted bool) {
+	item, err := c.newItem(key, value, ttl, c.expiryAt(c.clock.Now(), ttl))
+	if err != nil {
+		return evictedKey, false
+	}
+
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	removed := c.storeLocked(s, key, item)
+	s.mutex.Unlock()
+
+	c.notifyEvicted(removed)
+	for _, e := range removed {
+		if e.reason == ReasonCapacity {
+			return e.key, true
+		}
+	}
+	return evictedKey, false
+}
+
+// newItem builds the item to store for value under key. It returns ErrClosed
+// if the cache is closed, ErrItemTooLarge if the value's cost or estimated
+// size exceeds the share of the cache's maximum given to key's shard, and
+// errValuePanicked if the cost function, size estimator or copier panicked
+// and the panic was recovered by the WithPanicHandler handler.
+func (c *Cache[K, V]) newItem(key K, value V, ttl time.Duration, expiryTime time.Time) (cacheItem[V], error) {
+	item := cacheItem[V]{
+		expiryTime: expiryTime,
+		ttl:        ttl,
+	}
+	if c.closed.Load() {
+		return item, ErrClosed
 	}
+	err := errValuePanicked
+	c.guard(func() { err = c.admit(key, value, &item) })
+	return item, err
+}
+
+// admit sets item's cost, size and value from value, returning
+// ErrItemTooLarge if the cost or size exceeds key's shard's share.
+func (c *Cache[K, V]) admit(key K, value V, item *cacheItem[V]) error {
+	if c.costFn != nil {
+		item.cost = c.costFn(value)
+		if item.cost > c.shardFor(key).maxCost {
+			return ErrItemTooLarge
+		}
+	}
+	if c.maxBytes > 0 {
+		item.size = c.sizeOf(key, value)
+		if item.size > c.shardFor(key).maxBytes {
+			return ErrItemTooLarge
+		}
+	}
+	item.value = value
+	if c.copier != nil {
+		item.value = c.copier(value)
+	}
+	return nil
+}
+
+// storeLocked stores item under key in shard s, updating the eviction policy
+// and evicting entries as needed. It returns the entries that left the cache,
+// including a previous value for key. The caller must hold s's write lock.
+func (c *Cache[K, V]) storeLocked(s *shard[K, V], key K, item cacheItem[V]) []evictedEntry[K, V] {
+	var evicted []evictedEntry[K, V]
+	old, exists := s.data[key]
+	if exists && !old.negative {
+		reason := ReasonReplaced
+		if old.expired(c.clock.Now()) {
+			reason = ReasonExpired
+			c.stats.expirations.Add(1)
+		}
+		evicted = c.removedLocked(evicted, key, old.value, reason)
+	}
+	if exists {
+		s.untagLocked(key, old.tags)
+	}
+	s.data[key] = item
+	s.tagLocked(key, item.tags)
+	s.wakeLocked(key)
+	s.expiries.schedule(key, item.expiryTime)
+	c.stats.sets.Add(1)
+	if !item.negative {
+		c.publish(key, item.value, EventSet)
+	}
+	if s.policy != nil {
+		s.totalCost += item.cost - old.cost
+		s.totalBytes += item.size - old.size
+		if exists {
+			s.policy.update(key)
+		} else {
+			s.policy.add(key)
+		}
+		evicted = append(evicted, c.evictOverflow(s, &key)...)
+	}
+	return evicted
 }
 
 // Get retrieves a value from the cache by key.
 // It returns the value and a boolean indicating whether the key was found and not expired.
-func (c *SimpleCache[T]) Get(key string) (T, bool) {
-	c.mutex.RLock()
-	defer c.mutex.RUnlock()
-	item, exists := c.data[key]
-	var zero T
-	if !exists {
+// An expired item found by Get is removed immediately, and reported to the
+// eviction callback with ReasonExpired, rather than left for the janitor.
+// With sliding expiration enabled, a successful Get also pushes the item's
+// expiry forward by the TTL it was stored with, and WithSegmentedTTL extends
+// the expiry of frequently read items. With a maximum entry count, it
+// records the access with the eviction policy. With WithLoader, a miss loads
+// the value as Fetch does, reporting a failed load as a miss, and a hit is
+// refreshed ahead of expiry with WithRefreshAhead.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	if c.loader == nil {
+		return c.get(key)
+	}
+	v, err := c.getOrLoad(context.Background(), key, c.readThroughLoader(key))
+	return v, err == nil
+}
+
+// get is Get without read-through loading.
+func (c *Cache[K, V]) get(key K) (V, bool) {
+	if c.readsMutate() {
+		return c.getLocked(key)
+	}
+
+	s := c.shardFor(key)
+	s.mutex.RLock()
+	item, exists := s.data[key]
+	// Reading the clock is the costliest part of a hit, so skip it for items
+	// that never expire.
+	expired := exists && !item.expiryTime.IsZero() && item.expired(c.clock.Now())
+	s.mutex.RUnlock()
+
+	var zero V
+	if expired {
+		// Only the rare expired read pays for the write lock.
+		c.expireKey(s, key)
+		c.missed(key)
+		return zero, false
+	}
+	if !exists || item.negative {
+		c.missed(key)
 		return zero, false
 	}
 
-	if time.Now().After(item.expiryTime) {
-		// Item has expired, return zero value and false.
-		// Note: Expired items will be cleaned up by the janitor goroutine.
+	c.stats.hits.Add(1)
+	return c.copyValue(item.value), true
+}
+
+// missed records a Get miss for key and reports it to the WithOnMiss hook.
+func (c *Cache[K, V]) missed(key K) {
+	c.stats.misses.Add(1)
+	if c.onMiss != nil {
+		c.guard(func() { c.onMiss(key) })
+	}
+}
+
+// GetContext is like Get, but reports a miss without looking up key if ctx is
+// already done. Lookups never block, so without WithLoader ctx is only checked
+// on entry; with it, ctx is passed to the read-through load as for Fetch.
+func (c *Cache[K, V]) GetContext(ctx context.Context, key K) (V, bool) {
+	if ctx.Err() != nil {
+		var zero V
 		return zero, false
 	}
+	if c.loader == nil {
+		return c.get(key)
+	}
+	v, err := c.getOrLoad(ctx, key, c.readThroughLoader(key))
+	return v, err == nil
+}
 
-	return item.value, true
+// readsMutate reports whether reads update entries, in which case Get must take
+// the write lock.
+func (c *Cache[K, V]) readsMutate() bool {
+	return c.sliding || c.maxPromotedTTL > 0 || (c.policy != noPolicy && c.policy != fifoPolicyKind)
 }
 
-func (c *SimpleCache[T]) janitor() {
+// getLocked is the Get path for configurations where a read updates the item.
+func (c *Cache[K, V]) getLocked(key K) (V, bool) {
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	now := c.clock.Now()
+	item, exists := s.data[key]
+	if !exists || !item.live(now) {
+		expired := c.removeExpiredLocked(s, key, now)
+		s.mutex.Unlock()
+		c.notifyEvicted(expired)
+		c.missed(key)
+		var zero V
+		return zero, false
+	}
+
+	c.stats.hits.Add(1)
+	c.recordAccessLocked(s, key, item, now)
+	s.mutex.Unlock()
+	return c.copyValue(item.value), true
+}
+
+// expireKey removes key from shard s if it is still expired once the write
+// lock is held, reporting it to the eviction callback.
+func (c *Cache[K, V]) expireKey(s *shard[K, V], key K) {
+	s.mutex.Lock()
+	expired := c.removeExpiredLocked(s, key, c.clock.Now())
+	s.mutex.Unlock()
+
+	c.notifyEvicted(expired)
+}
+
+// removeExpiredLocked removes key from shard s if its item has expired at now,
+// returning the removed entry for the eviction callback. The caller must hold
+// s's write lock.
+func (c *Cache[K, V]) removeExpiredLocked(s *shard[K, V], key K, now time.Time) []evictedEntry[K, V] {
+	item, exists := s.data[key]
+	if !exists || !item.expired(now) {
+		return nil
+	}
+	s.removeLocked(key)
+	c.stats.expirations.Add(1)
+	if item.negative {
+		return nil
+	}
+	return c.removedLocked(nil, key, item.value, ReasonExpired)
+}
+
+// deleteLocked removes key from shard s on behalf of an explicit delete,
+// appending the entry to report to removed and returning whether it was live.
+// Expired items are reported with ReasonExpired, as the janitor would, and
+// cached loader misses are not reported. The caller must hold s's write lock.
+func (c *Cache[K, V]) deleteLocked(s *shard[K, V], key K, now time.Time, removed []evictedEntry[K, V]) ([]evictedEntry[K, V], bool) {
+	item, exists := s.removeLocked(key)
+	switch {
+	case !exists || item.negative:
+		return removed, false
+	case item.expired(now):
+		c.stats.expirations.Add(1)
+		return c.removedLocked(removed, key, item.value, ReasonExpired), false
+	default:
+		return c.removedLocked(removed, key, item.value, ReasonDeleted), true
+	}
+}
+
+// recordAccessLocked applies the side effects of reading item: sliding or
+// extending its expiry and notifying the eviction policy. The caller must hold
+// s's write lock.
+func (c *Cache[K, V]) recordAccessLocked(s *shard[K, V], key K, item cacheItem[V], now time.Time) {
+	if c.sliding && !item.expiryTime.IsZero() {
+		item.expiryTime = c.expiryAt(now, item.ttl)
+		s.data[key] = item
+		s.expiries.schedule(key, item.expiryTime)
+	}
+	if c.maxPromotedTTL > 0 && !item.expiryTime.IsZero() {
+		if item.hits < math.MaxUint32 {
+			item.hits++
+		}
+		if int64(item.hits) >= int64(c.promoteAfter) {
+			extended := item.expiryTime.Add(item.ttl)
+			if limit := now.Add(c.maxPromotedTTL); extended.After(limit) {
+				extended = limit
+			}
+			if extended.After(item.expiryTime) {
+				item.expiryTime = extended
+				s.expiries.schedule(key, item.expiryTime)
+			}
+		}
+		s.data[key] = item
+	}
+	if s.policy != nil {
+		s.policy.access(key)
+	}
+}
+
+// Peek returns the value for key and whether it was found and not expired, as
+// Get does, but without any side effects: it does not slide the item's expiry,
+// update LRU recency or LFU frequency, record hits or misses, or remove an
+// expired item. Use it to inspect the cache, e.g. from health checks, without
+// disturbing what it keeps; use Get for ordinary reads. Peek never loads, even
+// with WithLoader.
+func (c *Cache[K, V]) Peek(key K) (V, bool) {
+	s := c.shardFor(key)
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	item, exists := s.data[key]
+	if !exists || !item.live(c.clock.Now()) {
+		var zero V
+		return zero, false
+	}
+	return c.copyValue(item.value), true
+}
+
+// Has reports whether a live entry exists for key without copying its value.
+// Items whose expiry has passed are reported as absent, as with Get.
+func (c *Cache[K, V]) Has(key K) bool {
+	s := c.shardFor(key)
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	item, exists := s.data[key]
+	return exists && item.live(c.clock.Now())
+}
+
+// TTL returns the remaining lifetime of a live entry and true.
+// For items that never expire it returns NoExpiration and true; for missing or
+// expired keys it returns 0 and false. The result is the time left until the
+// item's expiry according to the cache's clock, so if a custom Clock goes
+// backwards, it grows by the size of the jump, and so does the item's life.
+func (c *Cache[K, V]) TTL(key K) (time.Duration, bool) {
+	s := c.shardFor(key)
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	now := c.clock.Now()
+	item, exists := s.data[key]
+	if !exists || !item.live(now) {
+		return 0, false
+	}
+	if item.expiryTime.IsZero() {
+		return NoExpiration, true
+	}
+	return item.expiryTime.Sub(now), true
+}
+
+// GetWithExpiry returns the value for key, the absolute time at which it
+// expires, and whether it was found and not expired. Items that never expire
+// report the zero time.Time, so check IsZero before deriving a lifetime from
+// it. Like Peek, it does not slide the item's expiry or update LRU recency or
+// LFU frequency, but it does count towards hits and misses.
+func (c *Cache[K, V]) GetWithExpiry(key K) (V, time.Time, bool) {
+	s := c.shardFor(key)
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	item, exists := s.data[key]
+	if !exists || !item.live(c.clock.Now()) {
+		c.stats.misses.Add(1)
+		var zero V
+		return zero, time.Time{}, false
+	}
+	c.stats.hits.Add(1)
+	return c.copyValue(item.value), item.expiryTime, true
+}
+
+// ExpiringWithin returns the keys of the entries whose remaining TTL is
+// positive but less than d, in no particular order, e.g. for a background job
+// that refreshes them before they expire. Entries that never expire or have
+// already expired are skipped. It scans every entry under every shard's read
+// lock, with one reading of the clock, and has no side effects. The returned
+// slice is never nil and is owned by the caller.
+func (c *Cache[K, V]) ExpiringWithin(d time.Duration) []K {
+	c.rlockAll()
+	defer c.runlockAll()
+	now := c.clock.Now()
+	keys := []K{}
+	for _, s := range c.shards {
+		for k, it := range s.data {
+			if it.negative || it.expiryTime.IsZero() || !now.Before(it.expiryTime) {
+				continue
+			}
+			if it.expiryTime.Sub(now) < d {
+				keys = append(keys, k)
+			}
+		}
+	}
+	return keys
+}
+
+// GetAllowStale returns the value for key even if it has expired, as long as
+// it is still stored: fresh reports whether the value is within its TTL, and
+// found whether a value was returned at all. Use it to serve a stale value
+// while a fresh one is loaded, e.g. with GetOrLoad in the background, as in a
+// stale-while-revalidate pattern; WithRefreshAhead avoids staleness for keys
+// read shortly before they expire.
+//
+// An expired value is only available until it is removed: by the janitor on
+// its next sweep, or by a Get, Delete or other operation that finds it
+// expired. GetAllowStale itself never removes it. Lengthen or disable the
+// cleanup interval to keep stale values around for longer. Like
+// GetWithExpiry, it does not slide the item's expiry or update LRU recency or
+// LFU frequency, and counts a fresh value as a hit and anything else as a miss.
+func (c *Cache[K, V]) GetAllowStale(key K) (value V, fresh, found bool) {
+	s := c.shardFor(key)
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	item, exists := s.data[key]
+	if !exists || item.negative {
+		c.stats.misses.Add(1)
+		return value, false, false
+	}
+	fresh = !item.expired(c.clock.Now())
+	if fresh {
+		c.stats.hits.Add(1)
+	} else {
+		c.stats.misses.Add(1)
+	}
+	return c.copyValue(item.value), fresh, true
+}
+
+// Touch resets the expiration of a live entry to ttl from now without changing
+// its value. It returns false, and leaves the cache untouched, if the key is
+// missing or already expired.
+func (c *Cache[K, V]) Touch(key K, ttl time.Duration) bool {
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := c.clock.Now()
+	item, exists := s.data[key]
+	if !exists || !item.live(now) {
+		return false
+	}
+	item.expiryTime = c.expiryAt(now, ttl)
+	item.ttl = ttl
+	s.data[key] = item
+	s.expiries.schedule(key, item.expiryTime)
+	return true
+}
+
+// TouchDefault resets the expiration of a live entry to the TTL it was stored
+// with, or last given by Touch, measured from now, as a read does with
+// WithSlidingExpiration. Every entry keeps its own TTL, so an entry stored with
+// SetWithTTL is renewed with that TTL rather than the cache's default. It
+// returns false if the key is missing or already expired, and leaves entries
+// that never expire unchanged.
+func (c *Cache[K, V]) TouchDefault(key K) bool {
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := c.clock.Now()
+	item, exists := s.data[key]
+	if !exists || !item.live(now) {
+		return false
+	}
+	if item.expiryTime.IsZero() {
+		return true
+	}
+	item.expiryTime = c.expiryAt(now, item.ttl)
+	s.data[key] = item
+	s.expiries.schedule(key, item.expiryTime)
+	return true
+}
+
+// Delete removes a key from the cache. It is a no-op if the key is absent.
+// The eviction callback, if any, receives ReasonDeleted, or ReasonExpired for
+// an entry that had already expired.
+func (c *Cache[K, V]) Delete(key K) {
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	deleted, _ := c.deleteLocked(s, key, c.clock.Now(), nil)
+	s.mutex.Unlock()
+
+	c.notifyEvicted(deleted)
+}
+
+// Len returns the number of live entries in the cache.
+// Expired items that have not yet been removed by the janitor are not counted,
+// so the result matches what Get would report.
+func (c *Cache[K, V]) Len() int {
+	c.rlockAll()
+	defer c.runlockAll()
+	now := c.clock.Now()
+	n := 0
+	for _, s := range c.shards {
+		for _, it := range s.data {
+			if it.live(now) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// Keys returns a snapshot of all live keys in the cache in no particular order.
+// The returned slice is never nil and is owned by the caller.
+func (c *Cache[K, V]) Keys() []K {
+	c.rlockAll()
+	defer c.runlockAll()
+	now := c.clock.Now()
+	keys := make([]K, 0, c.storedLocked())
+	for _, s := range c.shards {
+		for k, it := range s.data {
+			if it.live(now) {
+				keys = append(keys, k)
+			}
+		}
+	}
+	return keys
+}
+
+// Items returns a snapshot of all live entries. The returned map is owned by
+// the caller, and may be stale as soon as Items returns.
+func (c *Cache[K, V]) Items() map[K]V {
+	c.rlockAll()
+	defer c.runlockAll()
+	now := c.clock.Now()
+	items := make(map[K]V, c.storedLocked())
+	for _, s := range c.shards {
+		for k, it := range s.data {
+			if it.live(now) {
+				items[k] = c.copyValue(it.value)
+			}
+		}
+	}
+	return items
+}
+
+// Range calls fn for each live entry, in no particular order, until fn
+// returns false. The read lock is held for the whole iteration, so fn must not
+// call any method on the cache; use Items to iterate over a snapshot instead.
+func (c *Cache[K, V]) Range(fn func(key K, value V) bool) {
+	c.rlockAll()
+	defer c.runlockAll()
+	now := c.clock.Now()
+	for _, s := range c.shards {
+		for k, it := range s.data {
+			if !it.live(now) {
+				continue
+			}
+			if !fn(k, c.copyValue(it.value)) {
+				return
+			}
+		}
+	}
+}
+
+// Clear removes all entries from the cache. The janitor keeps running.
+func (c *Cache[K, V]) Clear() {
+	c.lockAll()
+	defer c.unlockAll()
+	for _, s := range c.shards {
+		s.reset()
+	}
+}
+
+// Drain removes every entry from the cache and returns the live ones, for
+// handing them off elsewhere, e.g. to another instance during a rolling
+// restart. All shards are locked for the duration, so no write is lost between
+// the copy and the removal. Because the entries are handed off rather than
+// discarded, Drain, like Clear, neither calls the eviction callback nor
+// publishes events. Expired entries are dropped.
+func (c *Cache[K, V]) Drain() map[K]V {
+	c.lockAll()
+	defer c.unlockAll()
+	now := c.clock.Now()
+	items := make(map[K]V, c.storedLocked())
+	for _, s := range c.shards {
+		for k, it := range s.data {
+			if it.live(now) {
+				items[k] = it.value
+			}
+		}
+		s.reset()
+	}
+	return items
+}
+
+// startJanitorLocked starts the janitor goroutine. The caller must hold
+// janitorMutex, or be the constructor.
+func (c *Cache[K, V]) startJanitorLocked() {
+	c.janitorRunning = true
+	interval := c.clampInterval(c.cleanupInterval)
+	c.interval.Store(int64(interval))
+	var offset time.Duration
+	if c.cleanupOffset != nil {
+		offset = c.cleanupOffset(interval)
+	}
+	c.wg.Add(1)
+	go c.janitor(interval, offset)
+}
+
+// randomCleanupOffset returns the delay before the first sweep of a janitor
+// with WithRandomizedCleanup, a random fraction of interval.
+func randomCleanupOffset(interval time.Duration) time.Duration {
+	return rand.N(interval)
+}
+
+// janitor sweeps the cache every interval. If offset is positive, the first
+// sweep happens after offset instead, and the regular interval starts from it.
+func (c *Cache[K, V]) janitor(interval, offset time.Duration) {
 	defer c.wg.Done()
-	ticker := time.NewTicker(c.cleanupInterval)
+	first := interval
+	if offset > 0 {
+		first = offset
+	}
+	ticker := time.NewTicker(first)
 	defer ticker.Stop()
+	lastDecay := time.Now()
 
 	for {
 		select {
 		case <-ticker.C:
-			now := time.Now()
-			c.mutex.Lock()
-			for k, it := range c.data {
-				if now.After(it.expiryTime) {
-					delete(c.data, k)
-				}
-			}
-			c.mutex.Unlock()
+			if first != interval {
+				first = interval
+				ticker.Reset(interval)
+			}
+			decay := c.decayInterval > 0 && time.Since(lastDecay) >= c.decayInterval
+			removed, left, ran := c.cleanup(decay)
+			if !ran {
+				continue
+			}
+			if decay {
+				lastDecay = time.Now()
+			}
+			if next := c.adaptInterval(interval, removed, left); next != interval {
+				interval = next
+				c.interval.Store(int64(interval))
+				ticker.Reset(interval)
+			}
+		case <-c.intervalChanged:
+			c.janitorMutex.Lock()
+			if c.cleanupInterval <= 0 {
+				c.janitorRunning = false
+				c.interval.Store(0)
+				c.janitorMutex.Unlock()
+				return
+			}
+			interval = c.clampInterval(c.cleanupInterval)
+			first = interval
+			c.interval.Store(int64(interval))
+			c.janitorMutex.Unlock()
+			ticker.Reset(interval)
 		case <-c.done:
+			c.interval.Store(0)
 			return
 		}
 	}
 }
 
-// Close stops the janitor goroutine and waits for it to exit.
-func (c *SimpleCache[T]) Close() {
+// cleanup runs the janitor's sweep, unless cleanup is paused, and reports
+// whether it ran along with the sweep's results.
+func (c *Cache[K, V]) cleanup(decay bool) (removed, left int, ran bool) {
+	if c.paused.Load() {
+		return 0, 0, false
+	}
+	removed, left = c.sweep(decay)
+	return removed, left, true
+}
+
+// clampInterval bounds d by the WithAdaptiveCleanup limits, if set.
+func (c *Cache[K, V]) clampInterval(d time.Duration) time.Duration {
+	if c.maxCleanup <= 0 {
+		return d
+	}
+	return min(max(d, c.minCleanup), c.maxCleanup)
+}
+
+// adaptInterval returns the janitor's next interval after a sweep that
+// removed removed entries and left left in place. Without WithAdaptiveCleanup
+// the interval never changes.
+func (c *Cache[K, V]) adaptInterval(interval time.Duration, removed, left int) time.Duration {
+	total := removed + left
+	switch {
+	case c.maxCleanup <= 0:
+		return interval
+	case removed > 0 && removed*4 >= total:
+		return c.clampInterval(interval / 2)
+	case removed*20 <= total:
+		return c.clampInterval(interval * 2)
+	default:
+		return interval
+	}
+}
+
+// CleanupInterval reports how often the janitor currently runs, which with
+// WithAdaptiveCleanup changes as it adapts. It returns zero when the janitor
+// is disabled or the cache is closed.
+func (c *Cache[K, V]) CleanupInterval() time.Duration {
+	return time.Duration(c.interval.Load())
+}
+
+// SetCleanupInterval changes how often the janitor removes expired items. The
+// new interval applies from the janitor's next wake-up rather than after the
+// current one has elapsed. A non-positive d stops the janitor, as if the cache
+// had been created with a non-positive interval, and a later positive d starts
+// it again. With WithAdaptiveCleanup, the janitor restarts its adaptation from
+// d, clamped to the bounds. It has no effect once the cache is closed.
+func (c *Cache[K, V]) SetCleanupInterval(d time.Duration) {
+	c.janitorMutex.Lock()
+	defer c.janitorMutex.Unlock()
+	select {
+	case <-c.done:
+		return
+	default:
+	}
+
+	c.cleanupInterval = d
+	switch {
+	case c.janitorRunning:
+		// The janitor may be calling back into the cache from an eviction
+		// callback, so never block on it.
+		select {
+		case c.intervalChanged <- struct{}{}:
+		default:
+		}
+	case d > 0:
+		c.startJanitorLocked()
+	}
+}
+
+// DeleteExpired removes all expired items now, reporting each to the eviction
+// callback with ReasonExpired, and returns how many were removed. It is useful
+// when the janitor is disabled or paused, and is safe to call while the janitor
+// runs: each item is removed by exactly one of them.
+func (c *Cache[K, V]) DeleteExpired() int {
+	removed, _ := c.sweep(false)
+	return removed
+}
+
+// sweep removes all expired items from every shard, halving LFU access counts
+// as it goes if decay is true, and returns how many items it removed and how
+// many it left in place. With WithBatchEvictionHandler, the removed items are
+// reported to the handler in one call at the end; otherwise each shard's are
+// reported once its lock is released.
+func (c *Cache[K, V]) sweep(decay bool) (removed, left int) {
+	var batch []evictedEntry[K, V]
+	for _, s := range c.shards {
+		expired, n, l := c.reapShard(s)
+		removed += n
+		left += l
+		if c.onBatchEvicted != nil {
+			batch = append(batch, expired...)
+		} else {
+			c.notifyEvicted(expired)
+		}
+		if decay {
+			c.decayFrequencies(s)
+		}
+	}
+	c.notifyBatch(batch, ReasonExpired)
+	return removed, left
+}
+
+// reapShard removes all expired items from shard s, returning those to report
+// if removals are observed, how many it removed and how many it left. Only
+// items that are due are visited.
+func (c *Cache[K, V]) reapShard(s *shard[K, V]) ([]evictedEntry[K, V], int, int) {
+	now := c.clock.Now()
+	collect := c.observesRemovals()
+	var expired []evictedEntry[K, V]
+	n := 0
+	s.mutex.Lock()
+	for {
+		e, ok := s.expiries.next()
+		if !ok || !now.After(e.at) {
+			break
+		}
+		key := e.key
+		it, removed := s.removeLocked(key)
+		if !removed {
+			s.expiries.remove(key)
+			continue
+		}
+		n++
+		c.stats.expirations.Add(1)
+		if collect && !it.negative {
+			expired = c.removedLocked(expired, key, it.value, ReasonExpired)
+		}
+	}
+	left := len(s.data)
+	s.mutex.Unlock()
+
+	return expired, n, left
+}
+
+// PauseCleanup stops the janitor from removing expired items until
+// ResumeCleanup is called, e.g. during a bulk import. Expired items are still
+// never served, and Get still removes those it finds. Pausing an already
+// paused cache is a no-op.
+func (c *Cache[K, V]) PauseCleanup() {
+	c.paused.Store(true)
+}
+
+// ResumeCleanup lets the janitor remove expired items again from its next
+// tick. Resuming a cache that is not paused is a no-op.
+func (c *Cache[K, V]) ResumeCleanup() {
+	c.paused.Store(false)
+}
+
+// Close stops the janitor goroutine and waits for it to exit. Closing a cache
+// more than once is a no-op, and Close is safe to call while other operations
+// are in flight; those that started before it may still complete.
+//
+// Once closed, the cache keeps serving what it holds but accepts no new
+// values. Reads such as Get behave as before on the entries already cached,
+// and Delete and the other removals still work, but expired items are no
+// longer removed in the background, only when they are read or deleted.
+// Writes are silent no-ops: Set and the setters that report success return
+// false, and those that return an error, such as Store and Increment, return
+// ErrClosed. GetOrLoad and Fetch return ErrClosed instead of loading a
+// missing key, and WaitForKey returns ErrClosed rather than wait for a value
+// that can no longer arrive.
+func (c *Cache[K, V]) Close() {
 	c.closeOnce.Do(func() {
+		c.closed.Store(true)
+		c.janitorMutex.Lock()
 		close(c.done)
+		c.janitorMutex.Unlock()
+		for _, s := range c.shards {
+			s.mutex.Lock()
+			for key := range s.waiters {
+				s.wakeLocked(key)
+			}
+			s.mutex.Unlock()
+		}
 	})
 	c.wg.Wait()
 }
+
+// CloseAndFlush closes the cache as Close does, then removes every remaining
+// entry, reporting each to the eviction callback with ReasonShutdown so that
+// the callback can release resources held by values still in the cache.
+// Entries that had already expired are reported with ReasonExpired instead.
+// Shutdown removals are not published to subscribers. The flush happens only
+// once, on the first call; later calls, like Close, are no-ops. As the cache is
+// closed, it stays empty, except for writes that were already in flight.
+func (c *Cache[K, V]) CloseAndFlush() {
+	c.Close()
+	c.flushOnce.Do(func() {
+		c.notifyEvicted(c.flush())
+	})
+}
+
+// flush removes all entries, returning them for notifyEvicted if removals are
+// observed.
+func (c *Cache[K, V]) flush() []evictedEntry[K, V] {
+	collect := c.observesRemovals()
+	var flushed []evictedEntry[K, V]
+	c.lockAll()
+	defer c.unlockAll()
+	now := c.clock.Now()
+	for _, s := range c.shards {
+		for key, it := range s.data {
+			if it.negative {
+				continue
+			}
+			reason := ReasonShutdown
+			if it.expired(now) {
+				reason = ReasonExpired
+				c.stats.expirations.Add(1)
+			}
+			if collect {
+				flushed = c.removedLocked(flushed, key, it.value, reason)
+			}
+		}
+		s.reset()
+	}
+	return flushed
+}
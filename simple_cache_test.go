@@ -1,17 +1,54 @@
 package keyvalstore
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
+// isStored reports whether key is physically held by c, even if it has expired.
+func isStored[T any](c *SimpleCache[T], key string) bool {
+	s := c.shardFor(key)
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	_, stored := s.data[key]
+	return stored
+}
+
+// janitorRunning reports whether c's janitor goroutine is running.
+func janitorRunning[T any](c *SimpleCache[T]) bool {
+	c.janitorMutex.Lock()
+	defer c.janitorMutex.Unlock()
+	return c.janitorRunning
+}
+
+// eventually polls cond until it holds or a second has passed, and reports
+// whether it held.
+func eventually(cond func() bool) bool {
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return true
+}
+
 func TestSimpleCache_SetAndGet(t *testing.T) {
 	// Create a cache with a cleanup interval of 1 second
 	sut := NewSimpleCache[string](1 * time.Second)
 
 	// Set and Get a value
-	sut.Set("key1", 2*time.Second, "value1")
+	sut.SetWithTTL("key1", "value1", 2*time.Second)
 	val, found := sut.Get("key1")
 	if !found || val != "value1" {
 		t.Errorf("Expected to find key1 with value 'value1', got '%s', found: %v", val, found)
@@ -26,7 +63,7 @@ func TestSimpleCache_SetAndGet(t *testing.T) {
 func TestSimpleCache_DurationSetToZeroWillNotCache(t *testing.T) {
 	sut := NewSimpleCache[string](time.Millisecond)
 
-	sut.Set("key1", 0, "value1")
+	sut.SetWithTTL("key1", "value1", 0)
 	val, found := sut.Get("key1")
 	if found {
 		t.Errorf("Expected not to find key1, but got value '%s'", val)
@@ -36,7 +73,7 @@ func TestSimpleCache_DurationSetToZeroWillNotCache(t *testing.T) {
 func TestSimpleCache_Expiration(t *testing.T) {
 	sut := NewSimpleCache[string](1 * time.Millisecond)
 
-	sut.Set("key1", time.Millisecond*5, "value1")
+	sut.SetWithTTL("key1", "value1", time.Millisecond*5)
 	val, found := sut.Get("key1")
 	if !found || val != "value1" {
 		t.Errorf("Expected to find key1 with value 'value1', got '%s', found: %v", val, found)
@@ -61,7 +98,7 @@ func TestSimpleCache_ConcurrentAccess(t *testing.T) {
 		go func(id int) {
 			defer wg.Done()
 			for j := 0; j < numIterations; j++ {
-				sut.Set("key", time.Minute, id*j)
+				sut.SetWithTTL("key", id*j, time.Minute)
 			}
 		}(i)
 	}
@@ -78,6 +115,56 @@ func TestSimpleCache_ConcurrentAccess(t *testing.T) {
 	wg.Wait()
 }
 
+func TestSimpleCache_ConcurrentStress(t *testing.T) {
+	sut := NewSimpleCache(time.Millisecond, WithShards[int](4), WithSlidingExpiration[int]())
+	const numGoroutines = 16
+	const numIterations = 2000
+	const numKeys = 64
+
+	var wg sync.WaitGroup
+	wg.Add(numGoroutines)
+	for i := range numGoroutines {
+		go func() {
+			defer wg.Done()
+			for j := range numIterations {
+				key := fmt.Sprintf("key%d", (i*7+j)%numKeys)
+				switch j % 10 {
+				case 0:
+					sut.Delete(key)
+				case 1:
+					sut.Len()
+				case 2:
+					for _, k := range sut.Keys() {
+						sut.Get(k)
+					}
+				case 3:
+					items := sut.Items()
+					for k := range items {
+						items[k]++
+					}
+				case 4:
+					sut.Range(func(string, int) bool { return true })
+				case 5:
+					sut.GetMany([]string{key, "missing"})
+				case 6:
+					sut.SetCleanupInterval(time.Duration(j%3) * time.Millisecond)
+				case 7:
+					sut.DeleteExpired()
+				default:
+					sut.SetWithTTL(key, j, time.Duration(j%3)*time.Millisecond)
+					sut.Get(key)
+				}
+			}
+		}()
+	}
+	time.Sleep(5 * time.Millisecond)
+	sut.Close()
+	wg.Wait()
+
+	sut.Len()
+	sut.Items()
+}
+
 func TestSimpleCache_DifferentTypes(t *testing.T) {
 	tests := []struct {
 		name string
@@ -87,7 +174,7 @@ func TestSimpleCache_DifferentTypes(t *testing.T) {
 			name: "string cache",
 			run: func(t *testing.T) {
 				c := NewSimpleCache[string](1 * time.Minute)
-				c.Set("strKey", 1*time.Minute, "stringValue")
+				c.SetWithTTL("strKey", "stringValue", 1*time.Minute)
 				v, ok := c.Get("strKey")
 				if !ok || v != "stringValue" {
 					t.Errorf("Expected to find strKey with value 'stringValue', got '%s', found: %v", v, ok)
@@ -98,7 +185,7 @@ func TestSimpleCache_DifferentTypes(t *testing.T) {
 			name: "int cache",
 			run: func(t *testing.T) {
 				c := NewSimpleCache[int](1 * time.Minute)
-				c.Set("intKey", 1*time.Minute, 42)
+				c.SetWithTTL("intKey", 42, 1*time.Minute)
 				v, ok := c.Get("intKey")
 				if !ok || v != 42 {
 					t.Errorf("Expected to find intKey with value 42, got '%d', found: %v", v, ok)
@@ -114,7 +201,7 @@ func TestSimpleCache_DifferentTypes(t *testing.T) {
 				}
 				c := NewSimpleCache[testStruct](1 * time.Minute)
 				expected := testStruct{Field1: "test", Field2: 100}
-				c.Set("structKey", 1*time.Minute, expected)
+				c.SetWithTTL("structKey", expected, 1*time.Minute)
 				v, ok := c.Get("structKey")
 				if !ok || v != expected {
 					t.Errorf("Expected to find structKey with value %+v, got %+v, found: %v", expected, v, ok)
@@ -127,3 +214,1275 @@ func TestSimpleCache_DifferentTypes(t *testing.T) {
 		t.Run(tc.name, tc.run)
 	}
 }
+
+func TestSimpleCache_Delete(t *testing.T) {
+	sut := NewSimpleCache[string](1 * time.Minute)
+
+	sut.SetWithTTL("key1", "value1", time.Minute)
+	sut.Delete("key1")
+	val, found := sut.Get("key1")
+	if found {
+		t.Errorf("Expected key1 to be deleted, but got value '%s'", val)
+	}
+
+	// Deleting an absent key must be a no-op.
+	sut.Delete("key1")
+	sut.Delete("never-set")
+}
+
+func TestSimpleCache_Len(t *testing.T) {
+	sut := NewSimpleCache[string](1 * time.Minute)
+
+	if n := sut.Len(); n != 0 {
+		t.Errorf("Expected empty cache to have length 0, got %d", n)
+	}
+
+	sut.SetWithTTL("key1", "value1", time.Minute)
+	sut.SetWithTTL("key2", "value2", time.Minute)
+	sut.SetWithTTL("expired", "value3", -time.Second)
+	if n := sut.Len(); n != 2 {
+		t.Errorf("Expected length 2 excluding expired items, got %d", n)
+	}
+}
+
+func TestSimpleCache_Keys(t *testing.T) {
+	sut := NewSimpleCache[string](1 * time.Minute)
+
+	keys := sut.Keys()
+	if keys == nil || len(keys) != 0 {
+		t.Errorf("Expected an empty non-nil slice, got %#v", keys)
+	}
+
+	sut.SetWithTTL("key1", "value1", time.Minute)
+	sut.SetWithTTL("key2", "value2", time.Minute)
+	sut.SetWithTTL("expired", "value3", -time.Second)
+
+	keys = sut.Keys()
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "key1" || keys[1] != "key2" {
+		t.Errorf("Expected keys [key1 key2], got %v", keys)
+	}
+
+	// Mutating the returned slice must not affect the cache.
+	keys[0] = "mutated"
+	if _, found := sut.Get("key1"); !found {
+		t.Errorf("Expected key1 to still be present after mutating the keys snapshot")
+	}
+}
+
+func TestSimpleCache_Clear(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(1*time.Millisecond, WithClock[string](clock))
+	defer sut.Close()
+
+	sut.SetWithTTL("key1", "value1", time.Minute)
+	sut.SetWithTTL("key2", "value2", time.Minute)
+	sut.Clear()
+
+	if _, found := sut.Get("key1"); found {
+		t.Errorf("Expected key1 to be removed by Clear")
+	}
+	if n := sut.Len(); n != 0 {
+		t.Errorf("Expected length 0 after Clear, got %d", n)
+	}
+
+	// The cache remains usable, and the janitor still reaps expired items.
+	sut.SetWithTTL("key3", "value3", time.Minute)
+	clock.Advance(2 * time.Minute)
+	if !eventually(func() bool { return !isStored(sut, "key3") }) {
+		t.Errorf("Expected the janitor to keep running after Clear")
+	}
+}
+
+func TestSimpleCache_Has(t *testing.T) {
+	sut := NewSimpleCache[string](1 * time.Minute)
+
+	sut.SetWithTTL("key1", "value1", time.Minute)
+	sut.SetWithTTL("expired", "value2", -time.Second)
+
+	if !sut.Has("key1") {
+		t.Errorf("Expected Has to report key1 as present")
+	}
+	if sut.Has("expired") {
+		t.Errorf("Expected Has to report an expired key as absent")
+	}
+	if sut.Has("missing") {
+		t.Errorf("Expected Has to report a missing key as absent")
+	}
+}
+
+func TestSimpleCache_ZeroCleanupIntervalDisablesJanitor(t *testing.T) {
+	sut := NewSimpleCache[string](0)
+	defer sut.Close()
+
+	sut.SetWithTTL("key1", "value1", time.Minute)
+	val, found := sut.Get("key1")
+	if !found || val != "value1" {
+		t.Errorf("Expected to find key1 with value 'value1', got '%s', found: %v", val, found)
+	}
+
+	sut.SetWithTTL("expired", "value2", -time.Second)
+	if _, found := sut.Get("expired"); found {
+		t.Errorf("Expected expired key to be reported as absent without a janitor")
+	}
+}
+
+func TestSimpleCache_GetRemovesExpiredItem(t *testing.T) {
+	sut := NewSimpleCache[string](0)
+	defer sut.Close()
+	var evicted []string
+	sut.OnEvicted(func(key string, value string, reason EvictionReason) {
+		evicted = append(evicted, key+":"+reason.String())
+	})
+
+	sut.SetWithTTL("expired", "value1", -time.Second)
+	if _, found := sut.Get("expired"); found {
+		t.Errorf("Expected expired key to be reported as absent")
+	}
+	if isStored(sut, "expired") {
+		t.Errorf("Expected Get to remove the expired item")
+	}
+	if len(evicted) != 1 || evicted[0] != "expired:expired" {
+		t.Errorf("Expected the expiration to be reported once, got %v", evicted)
+	}
+	if n := sut.Stats().Expirations; n != 1 {
+		t.Errorf("Expected 1 expiration, got %d", n)
+	}
+}
+
+func TestSimpleCache_Peek(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithClock[string](clock), WithSlidingExpiration[string](), WithMaxEntries[string](2))
+	defer sut.Close()
+
+	sut.SetWithTTL("key1", "value1", time.Minute)
+	sut.SetWithTTL("key2", "value2", time.Minute)
+	clock.Advance(30 * time.Second)
+	if val, found := sut.Peek("key1"); !found || val != "value1" {
+		t.Errorf("Expected to peek key1 with value 'value1', got '%s', found: %v", val, found)
+	}
+	if ttl, _ := sut.TTL("key1"); ttl != 30*time.Second {
+		t.Errorf("Expected Peek not to slide the expiry, got TTL %v", ttl)
+	}
+	if st := sut.Stats(); st.Hits != 0 || st.Misses != 0 {
+		t.Errorf("Expected Peek not to record hits or misses, got %+v", st)
+	}
+
+	// key1 is still least recently used, so it is evicted first.
+	sut.SetWithTTL("key3", "value3", time.Minute)
+	if sut.Has("key1") || !sut.Has("key2") {
+		t.Errorf("Expected Peek not to update recency, so key1 would be evicted")
+	}
+
+	sut.SetWithTTL("expired", "value4", -time.Second)
+	if _, found := sut.Peek("expired"); found {
+		t.Errorf("Expected Peek to report an expired key as absent")
+	}
+	if !isStored(sut, "expired") {
+		t.Errorf("Expected Peek not to remove the expired item")
+	}
+}
+
+func TestSimpleCache_GetRemovesExpiredItemWithEvictionPolicy(t *testing.T) {
+	sut := NewSimpleCache(0, WithMaxEntries[string](10))
+	defer sut.Close()
+
+	sut.SetWithTTL("expired", "value1", -time.Second)
+	if _, found := sut.Get("expired"); found {
+		t.Errorf("Expected expired key to be reported as absent")
+	}
+	if isStored(sut, "expired") {
+		t.Errorf("Expected Get to remove the expired item")
+	}
+}
+
+func TestSimpleCache_SetUsesDefaultTTL(t *testing.T) {
+	sut := NewSimpleCache(1*time.Millisecond, WithDefaultTTL[string](5*time.Millisecond))
+	defer sut.Close()
+
+	sut.Set("key1", "value1")
+	val, found := sut.Get("key1")
+	if !found || val != "value1" {
+		t.Errorf("Expected to find key1 with value 'value1', got '%s', found: %v", val, found)
+	}
+
+	time.Sleep(15 * time.Millisecond)
+	if val, found = sut.Get("key1"); found {
+		t.Errorf("Expected key1 to expire after the default TTL, but got value '%s'", val)
+	}
+}
+
+func TestSimpleCache_SetWithoutDefaultTTLNeverExpires(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithClock[string](clock))
+
+	sut.Set("key1", "value1")
+	clock.Advance(time.Hour)
+	sut.sweep(false)
+	val, found := sut.Get("key1")
+	if !found || val != "value1" {
+		t.Errorf("Expected key1 to never expire, got '%s', found: %v", val, found)
+	}
+}
+
+func TestSimpleCache_SetForever(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithDefaultTTL[string](time.Millisecond), WithClock[string](clock))
+
+	sut.SetForever("config", "value1")
+	clock.Advance(time.Hour)
+	sut.sweep(false)
+
+	val, found := sut.Get("config")
+	if !found || val != "value1" {
+		t.Errorf("Expected config to never expire, got '%s', found: %v", val, found)
+	}
+	if !sut.Has("config") {
+		t.Errorf("Expected Has to report a never-expiring key as present")
+	}
+	if !isStored(sut, "config") {
+		t.Errorf("Expected the janitor to skip never-expiring items")
+	}
+}
+
+func TestSimpleCache_SetAt(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithClock[string](clock), WithJitter[string](0.5))
+	expireAt := clock.Now().Add(90 * time.Second)
+
+	if !sut.SetAt("token", "value1", expireAt) {
+		t.Errorf("Expected SetAt to store the item")
+	}
+	if _, expiry, found := sut.GetWithExpiry("token"); !found || !expiry.Equal(expireAt) {
+		t.Errorf("Expected the item to expire exactly at %v, got %v, found: %v", expireAt, expiry, found)
+	}
+	clock.Advance(89 * time.Second)
+	if !sut.Has("token") {
+		t.Errorf("Expected the item to be live until its expiry time")
+	}
+	clock.Advance(2 * time.Second)
+	if sut.Has("token") {
+		t.Errorf("Expected the item to expire after its expiry time")
+	}
+
+	sut.Set("past", "value2")
+	sut.SetAt("past", "value3", clock.Now().Add(-time.Second))
+	if val, found := sut.Get("past"); found {
+		t.Errorf("Expected an expiry time in the past to store an expired item, got '%s'", val)
+	}
+
+	sut.SetAt("forever", "value4", time.Time{})
+	if ttl, found := sut.TTL("forever"); !found || ttl != NoExpiration {
+		t.Errorf("Expected a zero expiry time to store an item that never expires, got %v, found: %v", ttl, found)
+	}
+}
+
+func TestSimpleCache_Touch(t *testing.T) {
+	sut := NewSimpleCache[string](1 * time.Minute)
+
+	sut.SetWithTTL("key1", "value1", 10*time.Millisecond)
+	if !sut.Touch("key1", time.Minute) {
+		t.Errorf("Expected Touch to succeed for a live key")
+	}
+	time.Sleep(15 * time.Millisecond)
+	val, found := sut.Get("key1")
+	if !found || val != "value1" {
+		t.Errorf("Expected key1 to survive past its original TTL, got '%s', found: %v", val, found)
+	}
+
+	sut.SetWithTTL("expired", "value2", -time.Second)
+	if sut.Touch("expired", time.Minute) {
+		t.Errorf("Expected Touch to fail for an expired key")
+	}
+	if sut.Has("expired") {
+		t.Errorf("Expected Touch not to resurrect an expired key")
+	}
+	if sut.Touch("missing", time.Minute) {
+		t.Errorf("Expected Touch to fail for a missing key")
+	}
+}
+
+func TestSimpleCache_TouchDefault(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithClock[string](clock), WithDefaultTTL[string](time.Hour))
+
+	sut.SetWithTTL("own", "value1", time.Minute)
+	sut.Set("default", "value2")
+	sut.SetForever("forever", "value3")
+	clock.Advance(30 * time.Second)
+
+	if !sut.TouchDefault("own") || !sut.TouchDefault("default") || !sut.TouchDefault("forever") {
+		t.Errorf("Expected TouchDefault to succeed for live keys")
+	}
+	if ttl, _ := sut.TTL("own"); ttl != time.Minute {
+		t.Errorf("Expected the key's own TTL to be renewed, got %v", ttl)
+	}
+	if ttl, _ := sut.TTL("default"); ttl != time.Hour {
+		t.Errorf("Expected the default TTL the key was stored with, got %v", ttl)
+	}
+	if ttl, _ := sut.TTL("forever"); ttl != NoExpiration {
+		t.Errorf("Expected a never-expiring key to stay so, got %v", ttl)
+	}
+
+	sut.Touch("own", 2*time.Minute)
+	clock.Advance(time.Minute)
+	sut.TouchDefault("own")
+	if ttl, _ := sut.TTL("own"); ttl != 2*time.Minute {
+		t.Errorf("Expected the TTL last given by Touch, got %v", ttl)
+	}
+
+	clock.Advance(3 * time.Minute)
+	if sut.TouchDefault("own") || sut.TouchDefault("missing") {
+		t.Errorf("Expected TouchDefault to fail for expired and missing keys")
+	}
+}
+
+func TestSimpleCache_TTL(t *testing.T) {
+	sut := NewSimpleCache[string](1 * time.Minute)
+
+	sut.SetWithTTL("key1", "value1", time.Minute)
+	ttl, found := sut.TTL("key1")
+	if !found || ttl <= 0 || ttl > time.Minute {
+		t.Errorf("Expected a remaining TTL within (0, 1m], got %v, found: %v", ttl, found)
+	}
+
+	sut.SetForever("forever", "value2")
+	if ttl, found = sut.TTL("forever"); !found || ttl != NoExpiration {
+		t.Errorf("Expected NoExpiration for a never-expiring key, got %v, found: %v", ttl, found)
+	}
+
+	sut.SetWithTTL("expired", "value3", -time.Second)
+	if ttl, found = sut.TTL("expired"); found || ttl != 0 {
+		t.Errorf("Expected (0, false) for an expired key, got %v, found: %v", ttl, found)
+	}
+	if ttl, found = sut.TTL("missing"); found || ttl != 0 {
+		t.Errorf("Expected (0, false) for a missing key, got %v, found: %v", ttl, found)
+	}
+}
+
+func TestSimpleCache_GetWithExpiry(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithClock[string](clock), WithSlidingExpiration[string]())
+
+	sut.SetWithTTL("key1", "value1", time.Hour)
+	val, expiry, found := sut.GetWithExpiry("key1")
+	if !found || val != "value1" || !expiry.Equal(clock.Now().Add(time.Hour)) {
+		t.Errorf("Expected 'value1' expiring in 1h, got '%s' at %v, found: %v", val, expiry, found)
+	}
+
+	clock.Advance(30 * time.Minute)
+	if _, expiry, _ = sut.GetWithExpiry("key1"); !expiry.Equal(clock.Now().Add(30 * time.Minute)) {
+		t.Errorf("Expected GetWithExpiry not to slide the expiry, got %v", expiry)
+	}
+
+	sut.SetForever("forever", "value2")
+	if _, expiry, found = sut.GetWithExpiry("forever"); !found || !expiry.IsZero() {
+		t.Errorf("Expected the zero time for a never-expiring key, got %v, found: %v", expiry, found)
+	}
+
+	clock.Advance(time.Hour)
+	if val, expiry, found = sut.GetWithExpiry("key1"); found || val != "" || !expiry.IsZero() {
+		t.Errorf("Expected an expired key to be reported as absent, got '%s' at %v, found: %v", val, expiry, found)
+	}
+}
+
+func TestSimpleCache_WithOnMiss(t *testing.T) {
+	for name, opts := range map[string][]Option[string]{
+		"unbounded": nil,
+		"lru":       {WithMaxEntries[string](10)},
+	} {
+		var missed []string
+		sut := NewSimpleCache(0, append(opts, WithOnMiss[string](func(key string) {
+			missed = append(missed, key)
+		}))...)
+
+		sut.Set("key1", "value1")
+		sut.SetWithTTL("expired", "value2", -time.Second)
+		sut.Get("key1")
+		sut.Get("missing")
+		sut.Get("expired")
+		sut.Peek("other")
+
+		if !slices.Equal(missed, []string{"missing", "expired"}) {
+			t.Errorf("%s: Expected misses [missing expired], got %v", name, missed)
+		}
+	}
+}
+
+func TestNewCache_WithOnMissKeyMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a hook for another key type to panic")
+		}
+	}()
+	NewCache[int](WithOnMiss[string](func(key string) {}))
+}
+
+func TestSimpleCache_ExpiringWithin(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithClock[string](clock), WithShards[string](4))
+
+	sut.SetWithTTL("soon", "value1", 30*time.Second)
+	sut.SetWithTTL("later", "value2", time.Hour)
+	sut.SetWithTTL("expired", "value3", -time.Second)
+	sut.SetForever("forever", "value4")
+
+	if keys := sut.ExpiringWithin(time.Minute); len(keys) != 1 || keys[0] != "soon" {
+		t.Errorf("Expected [soon], got %v", keys)
+	}
+	clock.Advance(time.Minute)
+	if keys := sut.ExpiringWithin(time.Minute); len(keys) != 0 {
+		t.Errorf("Expected no keys once soon has expired, got %v", keys)
+	}
+	keys := sut.ExpiringWithin(2 * time.Hour)
+	if len(keys) != 1 || keys[0] != "later" {
+		t.Errorf("Expected [later], got %v", keys)
+	}
+}
+
+func TestSimpleCache_WithSegmentedTTL(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithClock[string](clock), WithSegmentedTTL[string](2, 3*time.Minute))
+
+	sut.SetWithTTL("hot", "value1", time.Minute)
+	sut.SetWithTTL("cold", "value2", time.Minute)
+	start := clock.Now()
+
+	sut.Get("hot")
+	if _, expiry, _ := sut.GetWithExpiry("hot"); !expiry.Equal(start.Add(time.Minute)) {
+		t.Errorf("Expected a probationary entry to keep its expiry, got %v", expiry.Sub(start))
+	}
+	sut.Get("hot")
+	if _, expiry, _ := sut.GetWithExpiry("hot"); !expiry.Equal(start.Add(2 * time.Minute)) {
+		t.Errorf("Expected a promoted entry's expiry to be extended by its TTL, got %v", expiry.Sub(start))
+	}
+	for range 5 {
+		sut.Get("hot")
+	}
+	if _, expiry, _ := sut.GetWithExpiry("hot"); !expiry.Equal(start.Add(3 * time.Minute)) {
+		t.Errorf("Expected extensions to be capped at the maximum TTL, got %v", expiry.Sub(start))
+	}
+
+	clock.Advance(90 * time.Second)
+	if _, ok := sut.Get("cold"); ok {
+		t.Errorf("Expected the rarely read entry to expire with its TTL")
+	}
+	if _, ok := sut.Get("hot"); !ok {
+		t.Errorf("Expected the frequently read entry to survive")
+	}
+
+	sut.SetWithTTL("hot", "value3", time.Minute)
+	sut.Get("hot")
+	if _, expiry, _ := sut.GetWithExpiry("hot"); !expiry.Equal(clock.Now().Add(time.Minute)) {
+		t.Errorf("Expected a new value to start on probation, got %v", expiry.Sub(clock.Now()))
+	}
+}
+
+func TestSimpleCache_WithSegmentedTTLAndRefreshAhead(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0,
+		WithClock[string](clock),
+		WithSegmentedTTL[string](1, time.Hour),
+		WithRefreshAhead[string](2*time.Minute),
+	)
+
+	calls := 0
+	loader := func() (string, error) {
+		calls++
+		return "loaded", nil
+	}
+	sut.GetOrLoad("hot", time.Minute, loader)
+	for range 4 {
+		sut.GetOrLoad("hot", time.Minute, loader)
+		waitForLoads(t, sut)
+	}
+	if ttl, _ := sut.TTL("hot"); ttl != 5*time.Minute {
+		t.Errorf("Expected TTL to report the promoted expiry of 5m, got %v", ttl)
+	}
+	if calls != 1 {
+		t.Errorf("Expected no refresh while the promoted entry is far from expiry, loader ran %d times", calls)
+	}
+
+	clock.Advance(270 * time.Second)
+	sut.GetOrLoad("hot", time.Minute, loader)
+	waitForLoads(t, sut)
+	if calls != 2 {
+		t.Errorf("Expected a refresh once the entry is within the refresh window, loader ran %d times", calls)
+	}
+}
+
+func TestSimpleCache_GetAllowStale(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithClock[string](clock))
+
+	sut.SetWithTTL("key1", "value1", time.Minute)
+	if val, fresh, found := sut.GetAllowStale("key1"); !found || !fresh || val != "value1" {
+		t.Errorf("Expected a fresh 'value1', got '%s', fresh: %v, found: %v", val, fresh, found)
+	}
+
+	clock.Advance(2 * time.Minute)
+	if val, fresh, found := sut.GetAllowStale("key1"); !found || fresh || val != "value1" {
+		t.Errorf("Expected a stale 'value1', got '%s', fresh: %v, found: %v", val, fresh, found)
+	}
+	if !isStored(sut, "key1") {
+		t.Errorf("Expected GetAllowStale to leave the stale item in place")
+	}
+	if stats := sut.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Expected 1 hit and 1 miss, got %d and %d", stats.Hits, stats.Misses)
+	}
+
+	if _, ok := sut.Get("key1"); ok {
+		t.Errorf("Expected Get to report the stale item as absent")
+	}
+	if _, _, found := sut.GetAllowStale("key1"); found {
+		t.Errorf("Expected the stale value to be gone once Get removed it")
+	}
+	if _, _, found := sut.GetAllowStale("missing"); found {
+		t.Errorf("Expected a missing key not to be found")
+	}
+}
+
+func TestSimpleCache_SlidingExpiration(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithSlidingExpiration[string](), WithClock[string](clock))
+
+	sut.SetWithTTL("key1", "value1", 30*time.Second)
+	for i := 0; i < 5; i++ {
+		clock.Advance(10 * time.Second)
+		sut.sweep(false)
+		if _, found := sut.Get("key1"); !found {
+			t.Fatalf("Expected key1 to stay alive while being read, iteration %d", i)
+		}
+	}
+
+	clock.Advance(45 * time.Second)
+	sut.sweep(false)
+	if isStored(sut, "key1") {
+		t.Errorf("Expected the sweep to reap key1 once reads stopped")
+	}
+	if val, found := sut.Get("key1"); found {
+		t.Errorf("Expected key1 to expire once reads stopped, but got value '%s'", val)
+	}
+}
+
+func TestSimpleCache_Items(t *testing.T) {
+	sut := NewSimpleCache[string](1 * time.Minute)
+
+	sut.Set("key1", "value1")
+	sut.Set("key2", "value2")
+	sut.SetWithTTL("expired", "value3", -time.Second)
+
+	items := sut.Items()
+	if len(items) != 2 || items["key1"] != "value1" || items["key2"] != "value2" {
+		t.Errorf("Expected only key1 and key2 in the snapshot, got %v", items)
+	}
+
+	// Mutating the snapshot must not affect the cache.
+	items["key1"] = "mutated"
+	delete(items, "key2")
+	if val, _ := sut.Get("key1"); val != "value1" {
+		t.Errorf("Expected key1 to still be 'value1', got '%s'", val)
+	}
+	if !sut.Has("key2") {
+		t.Errorf("Expected key2 to still be present")
+	}
+}
+
+func TestSimpleCache_Range(t *testing.T) {
+	sut := NewSimpleCache[int](1 * time.Minute)
+
+	sut.Set("key1", 1)
+	sut.Set("key2", 2)
+	sut.Set("key3", 3)
+	sut.SetWithTTL("expired", 100, -time.Second)
+
+	sum := 0
+	sut.Range(func(key string, value int) bool {
+		sum += value
+		return true
+	})
+	if sum != 6 {
+		t.Errorf("Expected Range to visit only live entries summing to 6, got %d", sum)
+	}
+
+	visited := 0
+	sut.Range(func(key string, value int) bool {
+		visited++
+		return false
+	})
+	if visited != 1 {
+		t.Errorf("Expected Range to stop after fn returns false, visited %d", visited)
+	}
+}
+
+func TestSimpleCache_Drain(t *testing.T) {
+	sut := NewSimpleCache(1*time.Minute, WithShards[string](4))
+	defer sut.Close()
+
+	evicted := 0
+	sut.OnEvicted(func(string, string, EvictionReason) { evicted++ })
+	sut.Set("key1", "value1")
+	sut.Set("key2", "value2")
+	sut.SetWithTTL("expired", "value3", -time.Second)
+
+	drained := sut.Drain()
+	if len(drained) != 2 || drained["key1"] != "value1" || drained["key2"] != "value2" {
+		t.Errorf("Expected the 2 live entries to be drained, got %v", drained)
+	}
+	if n := sut.Len(); n != 0 || isStored(sut, "expired") {
+		t.Errorf("Expected the cache to be empty after Drain, got %d items", n)
+	}
+	if evicted != 0 {
+		t.Errorf("Expected Drain not to call the eviction callback, got %d calls", evicted)
+	}
+}
+
+func TestSimpleCache_PauseAndResumeCleanup(t *testing.T) {
+	sut := NewSimpleCache[string](0)
+
+	sut.PauseCleanup()
+	sut.PauseCleanup()
+	sut.SetWithTTL("key1", "value1", -time.Second)
+	sut.SetWithTTL("key2", "value2", -time.Second)
+	if _, _, ran := sut.cleanup(false); ran || !isStored(sut, "key1") || !isStored(sut, "key2") {
+		t.Errorf("Expected the paused janitor to leave expired items in place")
+	}
+	if _, found := sut.Get("key1"); found || isStored(sut, "key1") {
+		t.Errorf("Expected Get to remove an expired item while cleanup is paused")
+	}
+
+	sut.ResumeCleanup()
+	sut.ResumeCleanup()
+	if _, _, ran := sut.cleanup(false); !ran || isStored(sut, "key2") {
+		t.Errorf("Expected the resumed janitor to remove key2")
+	}
+}
+
+func TestSimpleCache_SetCleanupInterval(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(time.Hour, WithClock[string](clock))
+	defer sut.Close()
+
+	sut.SetCleanupInterval(time.Millisecond)
+	if !eventually(func() bool { return sut.CleanupInterval() == time.Millisecond }) {
+		t.Errorf("Expected the janitor to switch to the shortened interval, got %v", sut.CleanupInterval())
+	}
+	sut.SetWithTTL("key1", "value1", time.Minute)
+	clock.Advance(2 * time.Minute)
+	if !eventually(func() bool { return !isStored(sut, "key1") }) {
+		t.Errorf("Expected the shortened interval to reap key1")
+	}
+
+	sut.SetCleanupInterval(0)
+	if !eventually(func() bool { return !janitorRunning(sut) }) || sut.CleanupInterval() != 0 {
+		t.Errorf("Expected a non-positive interval to stop the janitor")
+	}
+}
+
+func TestSimpleCache_SetCleanupIntervalStartsJanitor(t *testing.T) {
+	sut := NewSimpleCache[string](0)
+	defer sut.Close()
+
+	sut.SetWithTTL("key1", "value1", -time.Second)
+	sut.SetCleanupInterval(time.Millisecond)
+	if !janitorRunning(sut) || !eventually(func() bool { return !isStored(sut, "key1") }) {
+		t.Errorf("Expected a positive interval to start the janitor")
+	}
+
+	sut.Close()
+	sut.SetCleanupInterval(time.Millisecond)
+}
+
+func TestSimpleCache_WithAdaptiveCleanup(t *testing.T) {
+	sut := NewSimpleCache(time.Millisecond, WithAdaptiveCleanup[string](2*time.Millisecond, 8*time.Millisecond))
+	defer sut.Close()
+
+	if d := sut.CleanupInterval(); d != 2*time.Millisecond {
+		t.Errorf("Expected the interval to start clamped to the minimum, got %v", d)
+	}
+	deadline := time.Now().Add(time.Second)
+	for sut.CleanupInterval() != 8*time.Millisecond && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if d := sut.CleanupInterval(); d != 8*time.Millisecond {
+		t.Errorf("Expected sweeps of an empty cache to back off to the maximum, got %v", d)
+	}
+
+	sut.Close()
+	if d := sut.CleanupInterval(); d != 0 {
+		t.Errorf("Expected no interval once closed, got %v", d)
+	}
+}
+
+func TestSimpleCache_WithRandomizedCleanup(t *testing.T) {
+	if d := randomCleanupOffset(time.Hour); d < 0 || d >= time.Hour {
+		t.Errorf("Expected a random offset within the interval, got %v", d)
+	}
+
+	var got time.Duration
+	offset := func(c *config[string]) {
+		c.cleanupOffset = func(interval time.Duration) time.Duration {
+			got = interval
+			return 10 * time.Millisecond
+		}
+	}
+
+	clock := newFakeClock()
+	sut := NewSimpleCache(time.Hour, WithClock[string](clock), WithRandomizedCleanup[string](), offset)
+	defer sut.Close()
+	if got != time.Hour {
+		t.Errorf("Expected the offset to be drawn from the interval, got %v", got)
+	}
+
+	sut.SetWithTTL("key1", "value1", time.Minute)
+	clock.Advance(2 * time.Minute)
+	deadline := time.Now().Add(time.Second)
+	for isStored(sut, "key1") && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if isStored(sut, "key1") {
+		t.Errorf("Expected the first sweep to happen at the offset rather than after the full interval")
+	}
+
+	sut.SetWithTTL("key2", "value2", time.Minute)
+	clock.Advance(2 * time.Minute)
+	time.Sleep(30 * time.Millisecond)
+	if !isStored(sut, "key2") {
+		t.Errorf("Expected later sweeps to wait for the full interval")
+	}
+}
+
+func TestSimpleCache_AdaptInterval(t *testing.T) {
+	sut := NewSimpleCache(0, WithAdaptiveCleanup[string](time.Second, time.Minute))
+
+	tests := []struct {
+		interval      time.Duration
+		removed, left int
+		want          time.Duration
+	}{
+		{10 * time.Second, 50, 50, 5 * time.Second},
+		{10 * time.Second, 1, 99, 20 * time.Second},
+		{10 * time.Second, 10, 90, 10 * time.Second},
+		{10 * time.Second, 0, 0, 20 * time.Second},
+		{time.Second, 100, 0, time.Second},
+		{time.Minute, 0, 100, time.Minute},
+	}
+	for _, tt := range tests {
+		if got := sut.adaptInterval(tt.interval, tt.removed, tt.left); got != tt.want {
+			t.Errorf("Expected %v after removing %d of %d at %v, got %v", tt.want, tt.removed, tt.removed+tt.left, tt.interval, got)
+		}
+	}
+	if d := sut.CleanupInterval(); d != 0 {
+		t.Errorf("Expected no interval with the janitor disabled, got %v", d)
+	}
+
+	fixed := NewSimpleCache[string](0)
+	if got := fixed.adaptInterval(time.Second, 100, 0); got != time.Second {
+		t.Errorf("Expected a fixed interval without WithAdaptiveCleanup, got %v", got)
+	}
+}
+
+func TestSimpleCache_DeleteExpired(t *testing.T) {
+	sut := NewSimpleCache[string](0, WithShards[string](4))
+	defer sut.Close()
+	var evicted []string
+	sut.OnEvicted(func(key string, value string, reason EvictionReason) {
+		evicted = append(evicted, key+":"+reason.String())
+	})
+
+	sut.SetWithTTL("key1", "value1", time.Minute)
+	sut.SetWithTTL("expired1", "value2", -time.Second)
+	sut.SetWithTTL("expired2", "value3", -time.Second)
+
+	if n := sut.DeleteExpired(); n != 2 {
+		t.Errorf("Expected 2 expired items to be removed, got %d", n)
+	}
+	sort.Strings(evicted)
+	if len(evicted) != 2 || evicted[0] != "expired1:expired" || evicted[1] != "expired2:expired" {
+		t.Errorf("Expected both expirations to be reported, got %v", evicted)
+	}
+	if isStored(sut, "expired1") || isStored(sut, "expired2") || !isStored(sut, "key1") {
+		t.Errorf("Expected only the expired items to be removed")
+	}
+	if n := sut.DeleteExpired(); n != 0 {
+		t.Errorf("Expected nothing left to remove, got %d", n)
+	}
+}
+
+func TestSimpleCache_DeleteExpiredWithRunningJanitor(t *testing.T) {
+	sut := NewSimpleCache[int](time.Millisecond)
+	defer sut.Close()
+	var removed atomic.Int64
+	sut.OnEvicted(func(key string, value int, reason EvictionReason) {
+		removed.Add(1)
+	})
+
+	const numItems = 1000
+	for i := 0; i < numItems; i++ {
+		sut.SetWithTTL(strconv.Itoa(i), i, -time.Second)
+	}
+	var wg sync.WaitGroup
+	var manual atomic.Int64
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			manual.Add(int64(sut.DeleteExpired()))
+		}()
+	}
+	wg.Wait()
+
+	eventually(func() bool { return removed.Load() >= numItems })
+	if n := removed.Load(); n != numItems {
+		t.Errorf("Expected each item to be removed exactly once, got %d removals", n)
+	}
+	if n := manual.Load(); n > numItems {
+		t.Errorf("Expected DeleteExpired to count at most %d removals, got %d", numItems, n)
+	}
+}
+
+func TestSimpleCache_WithJitter(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithClock[int](clock), WithJitter[int](0.5))
+	defer sut.Close()
+
+	ttls := make(map[time.Duration]bool)
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		sut.SetWithTTL(key, i, 100*time.Second)
+		ttl, _ := sut.TTL(key)
+		if ttl < 50*time.Second || ttl > 150*time.Second {
+			t.Errorf("Expected TTL within ±50%% of 100s, got %v", ttl)
+		}
+		ttls[ttl] = true
+	}
+	if len(ttls) < 2 {
+		t.Errorf("Expected jitter to spread TTLs, got %v", ttls)
+	}
+}
+
+func TestSimpleCache_WithoutJitter(t *testing.T) {
+	clock := newFakeClock()
+	sut := NewSimpleCache(0, WithClock[int](clock), WithJitter[int](0))
+	defer sut.Close()
+
+	for i := 0; i < 10; i++ {
+		key := strconv.Itoa(i)
+		sut.SetWithTTL(key, i, 100*time.Second)
+		if ttl, _ := sut.TTL(key); ttl != 100*time.Second {
+			t.Errorf("Expected exact TTL of 100s without jitter, got %v", ttl)
+		}
+	}
+}
+
+func TestSimpleCache_GetContext(t *testing.T) {
+	sut := NewSimpleCache[string](0)
+	defer sut.Close()
+	sut.Set("key1", "value1")
+
+	if val, found := sut.GetContext(context.Background(), "key1"); !found || val != "value1" {
+		t.Errorf("Expected to find key1 with value 'value1', got '%s', found: %v", val, found)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, found := sut.GetContext(ctx, "key1"); found {
+		t.Errorf("Expected a cancelled context to report a miss")
+	}
+}
+
+func TestSimpleCache_WithCopier(t *testing.T) {
+	sut := NewSimpleCache(0, WithCopier(slices.Clone[[]int]))
+
+	value := []int{1, 2, 3}
+	sut.Set("key1", value)
+	value[0] = 100
+	got, _ := sut.Get("key1")
+	if got[0] != 1 {
+		t.Errorf("Expected Set to store a copy, got %v", got)
+	}
+
+	got[1] = 200
+	if again, _ := sut.Get("key1"); again[1] != 2 {
+		t.Errorf("Expected Get to return a copy, got %v", again)
+	}
+	if items := sut.Items(); &items["key1"][0] == &got[0] {
+		t.Errorf("Expected Items to return copies")
+	}
+}
+
+func TestSimpleCache_WithoutCopierSharesValues(t *testing.T) {
+	sut := NewSimpleCache[[]int](0)
+
+	value := []int{1, 2, 3}
+	sut.Set("key1", value)
+	value[0] = 100
+	if got, _ := sut.Get("key1"); got[0] != 100 {
+		t.Errorf("Expected values to be stored without copying, got %v", got)
+	}
+}
+
+func TestSimpleCache_CloseAndFlush(t *testing.T) {
+	sut := NewSimpleCache[string](1 * time.Minute)
+
+	var evicted []string
+	sut.OnEvicted(func(key string, value string, reason EvictionReason) {
+		evicted = append(evicted, key+"="+value+":"+reason.String())
+	})
+	sut.Set("key1", "value1")
+	sut.Set("key2", "value2")
+	sut.SetWithTTL("expired", "value3", -time.Second)
+
+	sut.CloseAndFlush()
+	sort.Strings(evicted)
+	expected := []string{"expired=value3:expired", "key1=value1:shutdown", "key2=value2:shutdown"}
+	if !slices.Equal(evicted, expected) {
+		t.Errorf("Expected %v to be flushed, got %v", expected, evicted)
+	}
+	if n := sut.Len(); n != 0 {
+		t.Errorf("Expected an empty cache after CloseAndFlush, got %d items", n)
+	}
+
+	if sut.Set("key4", "value4") {
+		t.Errorf("Expected Set on a closed cache to be ignored")
+	}
+	sut.CloseAndFlush()
+	sut.Close()
+	if len(evicted) != len(expected) {
+		t.Errorf("Expected later calls not to flush again, got %v", evicted)
+	}
+}
+
+func TestSimpleCache_Closed(t *testing.T) {
+	sut := NewSimpleCache[int](time.Minute)
+	sut.Set("key1", 1)
+	sut.Close()
+
+	if val, ok := sut.Get("key1"); !ok || val != 1 {
+		t.Errorf("Expected Get to keep serving cached data, got %d (found: %v)", val, ok)
+	}
+	if sut.Set("key2", 2) || sut.SetNX("key2", 2, time.Minute) || sut.Update("key1", func(int, bool) (int, bool) { return 3, true }) {
+		t.Errorf("Expected writes to a closed cache to be ignored")
+	}
+	sut.SetMany(map[string]int{"key3": 3}, time.Minute)
+	sut.ReplaceAll(map[string]int{"key3": 3}, time.Minute)
+	if err := sut.Store("key2", 2, time.Minute); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected Store to return ErrClosed, got %v", err)
+	}
+	if _, err := Increment(sut, "key1", 1, time.Minute); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected Increment to return ErrClosed, got %v", err)
+	}
+	called := false
+	_, err := sut.GetOrLoad("key2", time.Minute, func() (int, error) {
+		called = true
+		return 2, nil
+	})
+	if !errors.Is(err, ErrClosed) || called {
+		t.Errorf("Expected GetOrLoad to return ErrClosed without loading, got %v", err)
+	}
+	if _, err := sut.WaitForKey(context.Background(), "key2"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected WaitForKey to return ErrClosed, got %v", err)
+	}
+	var saved bytes.Buffer
+	if err := NewSimpleCache[int](0).Save(&saved); err != nil {
+		t.Fatalf("Expected Save to succeed, got %v", err)
+	}
+	if err := sut.Load(&saved); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected Load to return ErrClosed, got %v", err)
+	}
+	if items := sut.Items(); len(items) != 1 || items["key1"] != 1 {
+		t.Errorf("Expected the cached data to be left as it was, got %v", items)
+	}
+	sut.Delete("key1")
+	if sut.Len() != 0 {
+		t.Errorf("Expected Delete to keep working on a closed cache")
+	}
+}
+
+func TestSimpleCache_ClosedTakesPrecedenceOverLimits(t *testing.T) {
+	sut := NewSimpleCache(0, WithMaxCost(1, func(v int64) int64 { return v }))
+	sut.Set("key1", 1)
+	sut.Close()
+
+	if err := sut.Store("key2", 5, time.Minute); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected Store of an oversized value to return ErrClosed, got %v", err)
+	}
+	if _, err := Increment(sut, "key1", 5, time.Minute); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected Increment past the limit to return ErrClosed, got %v", err)
+	}
+}
+
+func TestSimpleCache_CloseWakesWaiters(t *testing.T) {
+	sut := NewSimpleCache[string](0)
+
+	done := make(chan error)
+	go func() {
+		_, err := sut.WaitForKey(context.Background(), "key1")
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	sut.Close()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("Expected ErrClosed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected Close to wake the waiter")
+	}
+}
+
+func TestSimpleCache_CloseConcurrentWithOperations(t *testing.T) {
+	sut := NewSimpleCache(time.Millisecond, WithShards[int](4), WithMaxEntries[int](50))
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 500 {
+				key := fmt.Sprintf("key%d", (i*500+j)%100)
+				sut.SetWithTTL(key, j, time.Millisecond)
+				sut.Get(key)
+				sut.Delete(key)
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond)
+	sut.Close()
+	wg.Wait()
+
+	if sut.Set("after", 1) {
+		t.Errorf("Expected Set after Close to be ignored")
+	}
+}
+
+func TestNew_Defaults(t *testing.T) {
+	sut := New[string]()
+	defer sut.Close()
+
+	if sut.cleanupInterval != DefaultCleanupInterval || !sut.janitorRunning {
+		t.Errorf("Expected the janitor to run every %v, got %v, running: %v", DefaultCleanupInterval, sut.cleanupInterval, sut.janitorRunning)
+	}
+	sut.Set("key1", "value1")
+	if ttl, found := sut.TTL("key1"); !found || ttl != NoExpiration {
+		t.Errorf("Expected Set to store items that never expire by default, got %v, found: %v", ttl, found)
+	}
+}
+
+func TestNew_WithOptions(t *testing.T) {
+	clock := newFakeClock()
+	sut := New(
+		WithCleanupInterval[string](time.Millisecond),
+		WithDefaultTTL[string](time.Minute),
+		WithMaxEntries[string](1),
+		WithClock[string](clock),
+	)
+	defer sut.Close()
+
+	sut.Set("key1", "value1")
+	sut.Set("key2", "value2")
+	if sut.Has("key1") || !sut.Has("key2") {
+		t.Errorf("Expected the entry limit to evict key1")
+	}
+	if ttl, _ := sut.TTL("key2"); ttl != time.Minute {
+		t.Errorf("Expected the default TTL of 1m, got %v", ttl)
+	}
+	if sut.CleanupInterval() != time.Millisecond || !janitorRunning(sut) {
+		t.Errorf("Expected the janitor to run every 1ms, got %v", sut.CleanupInterval())
+	}
+	clock.Advance(2 * time.Minute)
+	sut.sweep(false)
+	if isStored(sut, "key2") {
+		t.Errorf("Expected a sweep to reap key2 once the default TTL passed")
+	}
+}
+
+func TestNew_WithInitialCapacity(t *testing.T) {
+	const n = 4096
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+	// Measure inserting into a fresh presized and unsized cache, with the
+	// keys already built so that only the cache's own allocations count.
+	allocs := func(opts ...Option[int]) float64 {
+		return testing.AllocsPerRun(5, func() {
+			sut := NewSimpleCache(0, opts...)
+			for i, key := range keys {
+				sut.SetForever(key, i)
+			}
+		})
+	}
+
+	presized, unsized := allocs(WithInitialCapacity[int](n)), allocs()
+	if presized >= unsized {
+		t.Errorf("Expected presizing to reduce allocations while filling, got %v presized vs %v unsized", presized, unsized)
+	}
+}
+
+func TestNew_IgnoresInvalidOptions(t *testing.T) {
+	sut := New(
+		WithCleanupInterval[string](0),
+		WithMaxEntries[string](-1),
+		WithClock[string](nil),
+		WithShards[string](-4),
+		WithInitialCapacity[string](-1),
+	)
+	defer sut.Close()
+
+	if sut.janitorRunning || sut.policy != noPolicy || len(sut.shards) != 1 || sut.initialCapacity != 0 {
+		t.Errorf("Expected invalid options to leave the defaults")
+	}
+	sut.Set("key1", "value1")
+	if val, found := sut.Get("key1"); !found || val != "value1" {
+		t.Errorf("Expected to find key1 with value 'value1', got '%s', found: %v", val, found)
+	}
+}
+
+func TestCache_IntKeys(t *testing.T) {
+	sut := NewCache[int, string](WithCleanupInterval[string](0), WithMaxEntries[string](2))
+	defer sut.Close()
+
+	sut.Set(1, "one")
+	sut.Set(2, "two")
+	sut.Get(1)
+	sut.Set(3, "three")
+	if sut.Has(2) {
+		t.Errorf("Expected key 2 to be evicted as least recently used")
+	}
+	if val, found := sut.Get(1); !found || val != "one" {
+		t.Errorf("Expected to find key 1 with value 'one', got '%s', found: %v", val, found)
+	}
+	keys := sut.Keys()
+	sort.Ints(keys)
+	if len(keys) != 2 || keys[0] != 1 || keys[1] != 3 {
+		t.Errorf("Expected keys [1 3], got %v", keys)
+	}
+
+	val, err := sut.GetOrLoad(4, time.Minute, func() (string, error) { return "four", nil })
+	if err != nil || val != "four" {
+		t.Errorf("Expected to load 'four', got '%s', err: %v", val, err)
+	}
+	if !CompareAndSwap(sut, 4, "four", "FOUR", time.Minute) {
+		t.Errorf("Expected CompareAndSwap to work with int keys")
+	}
+}
+
+func TestCache_StructKeys(t *testing.T) {
+	type userKey struct {
+		tenant string
+		id     int
+	}
+	sut := NewCache[userKey, int](WithCleanupInterval[int](0))
+	defer sut.Close()
+
+	sut.SetWithTTL(userKey{"acme", 1}, 10, time.Minute)
+	sut.SetWithTTL(userKey{"acme", 2}, 20, -time.Second)
+	if val, found := sut.Get(userKey{"acme", 1}); !found || val != 10 {
+		t.Errorf("Expected to find {acme 1} with value 10, got %d, found: %v", val, found)
+	}
+	if _, found := sut.Get(userKey{"acme", 2}); found {
+		t.Errorf("Expected {acme 2} to be expired")
+	}
+	if n, _ := Increment(sut, userKey{"acme", 1}, 5, time.Minute); n != 15 {
+		t.Errorf("Expected Increment to return 15, got %d", n)
+	}
+}
+
+const benchmarkKeys = 1024
+
+// benchmarkCache returns a cache holding benchmarkKeys entries, and their keys.
+func benchmarkCache(b *testing.B, opts ...Option[int]) (*SimpleCache[int], []string) {
+	b.Helper()
+	c := NewSimpleCache(0, opts...)
+	keys := make([]string, benchmarkKeys)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+		c.SetWithTTL(keys[i], i, time.Hour)
+	}
+	return c, keys
+}
+
+func BenchmarkGet(b *testing.B) {
+	b.Run("hit", func(b *testing.B) {
+		c, keys := benchmarkCache(b)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			c.Get(keys[i%benchmarkKeys])
+		}
+	})
+	b.Run("hit-forever", func(b *testing.B) {
+		c, keys := benchmarkCache(b)
+		for i, key := range keys {
+			c.SetForever(key, i)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			c.Get(keys[i%benchmarkKeys])
+		}
+	})
+	b.Run("miss", func(b *testing.B) {
+		c, _ := benchmarkCache(b)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			c.Get("missing")
+		}
+	})
+	b.Run("hit-lru", func(b *testing.B) {
+		c, keys := benchmarkCache(b, WithMaxEntries[int](2*benchmarkKeys))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			c.Get(keys[i%benchmarkKeys])
+		}
+	})
+}
+
+func BenchmarkSet(b *testing.B) {
+	b.Run("overwrite", func(b *testing.B) {
+		c, keys := benchmarkCache(b)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			c.SetWithTTL(keys[i%benchmarkKeys], i, time.Hour)
+		}
+	})
+	b.Run("lru", func(b *testing.B) {
+		c, keys := benchmarkCache(b, WithMaxEntries[int](benchmarkKeys/2))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			c.SetWithTTL(keys[i%benchmarkKeys], i, time.Hour)
+		}
+	})
+}
+
+func BenchmarkParallel(b *testing.B) {
+	for _, shards := range []int{1, 16} {
+		b.Run("get-hit/shards="+strconv.Itoa(shards), func(b *testing.B) {
+			c, keys := benchmarkCache(b, WithShards[int](shards))
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for i := 0; pb.Next(); i++ {
+					c.Get(keys[i%benchmarkKeys])
+				}
+			})
+		})
+		b.Run("get-miss/shards="+strconv.Itoa(shards), func(b *testing.B) {
+			c, _ := benchmarkCache(b, WithShards[int](shards))
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					c.Get("missing")
+				}
+			})
+		})
+		b.Run("set/shards="+strconv.Itoa(shards), func(b *testing.B) {
+			c, keys := benchmarkCache(b, WithShards[int](shards))
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for i := 0; pb.Next(); i++ {
+					c.SetWithTTL(keys[i%benchmarkKeys], i, time.Hour)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package keyvalstore
+
+import "unsafe"
+
+// sizeOf estimates the memory held by an entry for value under key, using the
+// cache's size estimator for the value if one is set.
+func (c *Cache[K, V]) sizeOf(key K, value V) int64 {
+	size := estimateSize(key)
+	if c.sizeFn != nil {
+		return size + c.sizeFn(value)
+	}
+	return size + estimateSize(value)
+}
+
+// estimateSize returns the in-memory size of v's type, plus the length of v's
+// contents if it is a string or byte slice. Other referenced memory is not
+// counted.
+func estimateSize[T any](v T) int64 {
+	size := int64(unsafe.Sizeof(v))
+	switch v := any(v).(type) {
+	case string:
+		size += int64(len(v))
+	case []byte:
+		size += int64(len(v))
+	}
+	return size
+}
+
+// EstimatedSize returns an estimate of the memory held by the cache's entries,
+// in bytes: for each stored entry, the size of its key and value as estimated
+// for WithMaxBytes, using WithSizeEstimator for values if it is set, plus a
+// fixed overhead for the entry's expiry, cost and other bookkeeping. Entries
+// that have expired but have not yet been removed are counted, as they still
+// hold memory. The estimate leaves out memory the cache allocates around its
+// entries, such as map buckets, the expiry queue and eviction policy state,
+// and memory referenced by values that the estimator does not count, so it is
+// best used to compare caches or watch one grow rather than as an exact
+// figure. It scans every entry, holding every shard's read lock.
+func (c *Cache[K, V]) EstimatedSize() int64 {
+	overhead := int64(unsafe.Sizeof(cacheItem[V]{})) - int64(unsafe.Sizeof(*new(V)))
+	c.rlockAll()
+	defer c.runlockAll()
+	var total int64
+	for _, s := range c.shards {
+		for key, it := range s.data {
+			size := it.size
+			if c.maxBytes <= 0 {
+				size = c.sizeOf(key, it.value)
+			}
+			total += size + overhead
+		}
+	}
+	return total
+}
@@ -0,0 +1,100 @@
+package keyvalstore
+
+import (
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestEstimateSize(t *testing.T) {
+	if size := estimateSize("hello"); size != int64(unsafe.Sizeof(""))+5 {
+		t.Errorf("Expected a string's header plus its length, got %d", size)
+	}
+	if size := estimateSize([]byte("abc")); size != int64(unsafe.Sizeof([]byte(nil)))+3 {
+		t.Errorf("Expected a byte slice's header plus its length, got %d", size)
+	}
+	if size := estimateSize(int64(1)); size != 8 {
+		t.Errorf("Expected an int64 to take 8 bytes, got %d", size)
+	}
+}
+
+func TestSimpleCache_MaxBytesEvictsUntilItemFits(t *testing.T) {
+	// Each entry below takes a one-byte key plus its header, and four bytes of value.
+	entry := estimateSize("a") + 4
+	sut := NewSimpleCache(1*time.Minute,
+		WithMaxBytes[string](2*entry),
+		WithSizeEstimator(func(v string) int64 { return int64(len(v)) }))
+	defer sut.Close()
+
+	sut.Set("a", "aaaa")
+	sut.Set("b", "bbbb")
+	if bytes := sut.Stats().Bytes; bytes != 2*entry {
+		t.Errorf("Expected %d bytes in use, got %d", 2*entry, bytes)
+	}
+	sut.Get("a") // recency: a, b
+	if !sut.Set("c", "cccc") {
+		t.Errorf("Expected c to be admitted")
+	}
+	if sut.Has("b") {
+		t.Errorf("Expected b to be evicted as least recently used")
+	}
+	if !sut.Has("a") || !sut.Has("c") {
+		t.Errorf("Expected a and c to be present, got keys %v", sut.Keys())
+	}
+
+	sut.Delete("a")
+	if bytes := sut.Stats().Bytes; bytes != entry {
+		t.Errorf("Expected %d bytes in use after a delete, got %d", entry, bytes)
+	}
+	sut.Clear()
+	if bytes := sut.Stats().Bytes; bytes != 0 {
+		t.Errorf("Expected no bytes in use after Clear, got %d", bytes)
+	}
+}
+
+func TestSimpleCache_MaxBytesRejectsOversizedItem(t *testing.T) {
+	sut := NewSimpleCache(1*time.Minute, WithMaxBytes[string](estimateSize("a")+estimateSize("aaa")))
+	defer sut.Close()
+
+	if !sut.Set("a", "aaa") {
+		t.Errorf("Expected an item within the budget to be admitted")
+	}
+	if sut.Set("a", "too large") {
+		t.Errorf("Expected an item larger than the maximum to be rejected")
+	}
+	val, found := sut.Get("a")
+	if !found || val != "aaa" {
+		t.Errorf("Expected a rejected Set to leave 'aaa' in place, got '%s', found: %v", val, found)
+	}
+}
+
+func TestSimpleCache_BytesUntrackedWithoutLimit(t *testing.T) {
+	sut := NewSimpleCache[string](1 * time.Minute)
+	defer sut.Close()
+
+	sut.Set("a", "aaa")
+	if bytes := sut.Stats().Bytes; bytes != 0 {
+		t.Errorf("Expected no byte tracking without WithMaxBytes, got %d", bytes)
+	}
+}
+
+func TestSimpleCache_EstimatedSize(t *testing.T) {
+	overhead := int64(unsafe.Sizeof(cacheItem[string]{})) - int64(unsafe.Sizeof(""))
+	sut := NewSimpleCache[string](0)
+	if size := sut.EstimatedSize(); size != 0 {
+		t.Errorf("Expected an empty cache to take 0 bytes, got %d", size)
+	}
+
+	sut.Set("a", "aaaa")
+	sut.SetWithTTL("bb", "b", -time.Second)
+	expected := estimateSize("a") + estimateSize("aaaa") + estimateSize("bb") + estimateSize("b") + 2*overhead
+	if size := sut.EstimatedSize(); size != expected {
+		t.Errorf("Expected %d bytes including the unreaped expired entry, got %d", expected, size)
+	}
+
+	byLength := NewSimpleCache(0, WithSizeEstimator(func(v string) int64 { return int64(len(v)) * 100 }))
+	byLength.Set("a", "aaaa")
+	if size, expected := byLength.EstimatedSize(), estimateSize("a")+400+overhead; size != expected {
+		t.Errorf("Expected the size estimator to be used, %d bytes, got %d", expected, size)
+	}
+}
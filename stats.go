@@ -0,0 +1,98 @@
+package keyvalstore
+
+import "sync/atomic"
+
+// Stats is a point-in-time snapshot of a cache's cumulative counters.
+type Stats struct {
+	// Hits counts Get calls that found a live item.
+	Hits uint64
+	// Misses counts Get calls for absent or expired keys.
+	Misses uint64
+	// Evictions counts items removed by a capacity policy.
+	Evictions uint64
+	// Expirations counts expired items removed by the janitor or Get, or overwritten.
+	Expirations uint64
+	// Sets counts values stored in the cache.
+	Sets uint64
+	// DroppedEvents counts events not delivered to a subscriber because its
+	// channel was full.
+	DroppedEvents uint64
+	// InFlightLoads is the number of loaders started by GetOrLoad that are
+	// running now. With WithMaxConcurrentLoads it never exceeds the limit.
+	InFlightLoads int64
+	// CircuitState is the state of the loader circuit breaker, and
+	// CircuitOpens counts how often it has opened. See WithCircuitBreaker.
+	CircuitState CircuitState
+	CircuitOpens uint64
+	// Bytes is the current estimated memory held by the cache's entries. It is
+	// only tracked for caches created with WithMaxBytes, and is 0 otherwise.
+	Bytes int64
+}
+
+// stats holds the live counters. They are updated atomically so recording them
+// never requires the cache's lock.
+type stats struct {
+	hits          atomic.Uint64
+	misses        atomic.Uint64
+	evictions     atomic.Uint64
+	expirations   atomic.Uint64
+	sets          atomic.Uint64
+	droppedEvents atomic.Uint64
+	inFlightLoads atomic.Int64
+}
+
+// Stats returns a snapshot of the cache's counters. Counters are read
+// individually, so a snapshot taken under concurrent use may not reflect a
+// single instant.
+func (c *Cache[K, V]) Stats() Stats {
+	circuitState, circuitOpens := c.breaker.snapshot(false)
+	return Stats{
+		Hits:          c.stats.hits.Load(),
+		Misses:        c.stats.misses.Load(),
+		Evictions:     c.stats.evictions.Load(),
+		Expirations:   c.stats.expirations.Load(),
+		Sets:          c.stats.sets.Load(),
+		DroppedEvents: c.stats.droppedEvents.Load(),
+		InFlightLoads: c.stats.inFlightLoads.Load(),
+		CircuitState:  circuitState,
+		CircuitOpens:  circuitOpens,
+		Bytes:         c.totalBytes(),
+	}
+}
+
+// StatsAndReset returns a snapshot of the cache's counters, as Stats does, and
+// restarts the cumulative counters from zero, so that successive calls report
+// what happened in between, e.g. for periodic reporting. Each counter is read
+// and zeroed in a single atomic step, so an operation that lands concurrently
+// is counted by exactly one call, never by two or by none. The gauges
+// InFlightLoads, CircuitState and Bytes describe the cache now and are not
+// reset; the cache's length is not a counter either.
+func (c *Cache[K, V]) StatsAndReset() Stats {
+	circuitState, circuitOpens := c.breaker.snapshot(true)
+	return Stats{
+		Hits:          c.stats.hits.Swap(0),
+		Misses:        c.stats.misses.Swap(0),
+		Evictions:     c.stats.evictions.Swap(0),
+		Expirations:   c.stats.expirations.Swap(0),
+		Sets:          c.stats.sets.Swap(0),
+		DroppedEvents: c.stats.droppedEvents.Swap(0),
+		InFlightLoads: c.stats.inFlightLoads.Load(),
+		CircuitState:  circuitState,
+		CircuitOpens:  circuitOpens,
+		Bytes:         c.totalBytes(),
+	}
+}
+
+// totalBytes sums the estimated size of the entries in every shard.
+func (c *Cache[K, V]) totalBytes() int64 {
+	if c.maxBytes <= 0 {
+		return 0
+	}
+	var total int64
+	for _, s := range c.shards {
+		s.mutex.RLock()
+		total += s.totalBytes
+		s.mutex.RUnlock()
+	}
+	return total
+}
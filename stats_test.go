@@ -0,0 +1,72 @@
+package keyvalstore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSimpleCache_Stats(t *testing.T) {
+	sut := NewSimpleCache(0, WithMaxEntries[string](2))
+
+	sut.Set("key1", "value1")
+	sut.Set("key2", "value2")
+	sut.Set("key3", "value3") // evicts key1
+	sut.Get("key2")
+	sut.Get("key1")
+	sut.SetWithTTL("expired", "value4", -time.Second) // evicts key3
+	sut.Get("expired")
+
+	expected := Stats{Hits: 1, Misses: 2, Evictions: 2, Expirations: 1, Sets: 4}
+	if got := sut.Stats(); got != expected {
+		t.Errorf("Expected stats %+v, got %+v", expected, got)
+	}
+}
+
+func TestSimpleCache_StatsAndReset(t *testing.T) {
+	sut := NewSimpleCache[string](0)
+
+	sut.Set("key1", "value1")
+	sut.Get("key1")
+	sut.Get("missing")
+	expected := Stats{Hits: 1, Misses: 1, Sets: 1}
+	if got := sut.StatsAndReset(); got != expected {
+		t.Errorf("Expected stats %+v, got %+v", expected, got)
+	}
+	if got := sut.Stats(); got != (Stats{}) {
+		t.Errorf("Expected counters to restart from zero, got %+v", got)
+	}
+
+	sut.Get("key1")
+	expected = Stats{Hits: 1}
+	if got := sut.StatsAndReset(); got != expected {
+		t.Errorf("Expected only the hit since the reset, got %+v", got)
+	}
+	if sut.Len() != 1 {
+		t.Errorf("Expected StatsAndReset to leave the entries alone")
+	}
+}
+
+func TestSimpleCache_StatsAndResetConcurrent(t *testing.T) {
+	sut := NewSimpleCache[string](0)
+	sut.Set("key1", "value1")
+
+	var total uint64
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 1000 {
+			sut.Get("key1")
+		}
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		total += sut.StatsAndReset().Hits
+	}
+	if total != 1000 {
+		t.Errorf("Expected every hit to be counted exactly once, got %d", total)
+	}
+}
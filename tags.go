@@ -0,0 +1,82 @@
+package keyvalstore
+
+import (
+	"slices"
+	"time"
+)
+
+// SetWithTags adds a key-value pair to the cache with an expiration time, as
+// SetWithTTL does, and associates the key with tags so that InvalidateTag can
+// remove it together with other keys sharing a tag. Storing the key again
+// replaces its tags with those of the new call; Set and SetWithTTL leave it
+// with none.
+func (c *Cache[K, V]) SetWithTags(key K, value V, ttl time.Duration, tags ...string) bool {
+	item, err := c.newItem(key, value, ttl, c.expiryAt(c.clock.Now(), ttl))
+	if err != nil {
+		return false
+	}
+	item.tags = slices.Clone(tags)
+
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	evicted := c.storeLocked(s, key, item)
+	s.mutex.Unlock()
+
+	c.notifyEvicted(evicted)
+	return true
+}
+
+// InvalidateTag removes every entry stored with tag, reporting each to the
+// eviction callback with ReasonDeleted, and returns the number of live entries
+// removed. Expired entries carrying the tag are removed too, and reported with
+// ReasonExpired as the janitor would. Each shard is locked once.
+func (c *Cache[K, V]) InvalidateTag(tag string) int {
+	n := 0
+	var removed []evictedEntry[K, V]
+	for _, s := range c.shards {
+		s.mutex.Lock()
+		now := c.clock.Now()
+		for key := range s.tags[tag] {
+			var live bool
+			removed, live = c.deleteLocked(s, key, now, removed)
+			if live {
+				n++
+			}
+		}
+		s.mutex.Unlock()
+	}
+
+	c.notifyEvicted(removed)
+	return n
+}
+
+// tagLocked adds key to the index of each of tags. The caller must hold the
+// write lock.
+func (s *shard[K, V]) tagLocked(key K, tags []string) {
+	if len(tags) == 0 {
+		return
+	}
+	if s.tags == nil {
+		s.tags = make(map[string]map[K]struct{})
+	}
+	for _, tag := range tags {
+		keys, ok := s.tags[tag]
+		if !ok {
+			keys = make(map[K]struct{})
+			s.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+}
+
+// untagLocked removes key from the index of each of tags, dropping tags that
+// no longer have any keys. The caller must hold the write lock.
+func (s *shard[K, V]) untagLocked(key K, tags []string) {
+	for _, tag := range tags {
+		keys := s.tags[tag]
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(s.tags, tag)
+		}
+	}
+}
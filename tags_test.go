@@ -0,0 +1,98 @@
+package keyvalstore
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+// indexedTags returns the number of tags indexed across c's shards.
+func indexedTags[T any](c *SimpleCache[T]) int {
+	n := 0
+	for _, s := range c.shards {
+		s.mutex.RLock()
+		n += len(s.tags)
+		s.mutex.RUnlock()
+	}
+	return n
+}
+
+func TestSimpleCache_InvalidateTag(t *testing.T) {
+	sut := NewSimpleCache(0, WithShards[string](4))
+	defer sut.Close()
+	var evicted []string
+	sut.OnEvicted(func(key string, value string, reason EvictionReason) {
+		evicted = append(evicted, key+":"+reason.String())
+	})
+
+	sut.SetWithTags("user:1:profile", "p", time.Minute, "user:1")
+	sut.SetWithTags("user:1:avatar", "a", time.Minute, "user:1", "images")
+	sut.SetWithTags("user:2:avatar", "b", time.Minute, "user:2", "images")
+	sut.Set("unrelated", "u")
+
+	if n := sut.InvalidateTag("user:1"); n != 2 {
+		t.Errorf("Expected 2 entries to be invalidated, got %d", n)
+	}
+	if sut.Has("user:1:profile") || sut.Has("user:1:avatar") {
+		t.Errorf("Expected entries tagged user:1 to be removed")
+	}
+	if !sut.Has("user:2:avatar") || !sut.Has("unrelated") {
+		t.Errorf("Expected other entries to be kept")
+	}
+	sort.Strings(evicted)
+	if len(evicted) != 2 || evicted[0] != "user:1:avatar:deleted" || evicted[1] != "user:1:profile:deleted" {
+		t.Errorf("Expected both removals to be reported as deleted, got %v", evicted)
+	}
+
+	if n := sut.InvalidateTag("images"); n != 1 {
+		t.Errorf("Expected the remaining image to be invalidated, got %d", n)
+	}
+	if n := sut.InvalidateTag("missing"); n != 0 {
+		t.Errorf("Expected an unknown tag to remove nothing, got %d", n)
+	}
+}
+
+func TestSimpleCache_InvalidateTagExpired(t *testing.T) {
+	sut := NewSimpleCache[string](0)
+	defer sut.Close()
+
+	sut.SetWithTags("live", "value1", time.Minute, "tag")
+	sut.SetWithTags("expired", "value2", -time.Second, "tag")
+	if n := sut.InvalidateTag("tag"); n != 1 {
+		t.Errorf("Expected only the live entry to be counted, got %d", n)
+	}
+	if isStored(sut, "expired") {
+		t.Errorf("Expected the expired entry to be removed as well")
+	}
+}
+
+func TestSimpleCache_TagIndexDoesNotLeak(t *testing.T) {
+	sut := NewSimpleCache(0, WithMaxEntries[string](1))
+	defer sut.Close()
+
+	sut.SetWithTags("deleted", "value1", time.Minute, "a")
+	sut.Delete("deleted")
+	sut.SetWithTags("expired", "value2", -time.Second, "b")
+	sut.DeleteExpired()
+	sut.SetWithTags("evicted", "value3", time.Minute, "c")
+	sut.SetWithTags("retagged", "value4", time.Minute, "d")
+	sut.Set("retagged", "value5")
+
+	if n := indexedTags(sut); n != 0 {
+		t.Errorf("Expected no tags left in the index, got %d", n)
+	}
+	if n := sut.InvalidateTag("d"); n != 0 {
+		t.Errorf("Expected Set to clear the key's tags, but %d entries were invalidated", n)
+	}
+}
+
+func TestSimpleCache_IncrementKeepsTags(t *testing.T) {
+	sut := NewSimpleCache[int](0)
+	defer sut.Close()
+
+	sut.SetWithTags("counter", 1, time.Minute, "counters")
+	Increment(sut, "counter", 1, time.Minute)
+	if n := sut.InvalidateTag("counters"); n != 1 {
+		t.Errorf("Expected the incremented counter to keep its tag, got %d removals", n)
+	}
+}
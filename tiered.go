@@ -0,0 +1,72 @@
+package keyvalstore
+
+import (
+	"errors"
+	"time"
+)
+
+// Backend is a slower second-level store behind a TieredCache, such as Redis
+// or Memcached. Implementations must be safe for concurrent use.
+type Backend[T any] interface {
+	// Get returns the value stored under key and whether it was found. A
+	// missing key is reported as found == false with a nil error.
+	Get(key string) (T, bool, error)
+	// Set stores value under key for ttl. A non-positive ttl means the value
+	// is already expired, as with SetWithTTL, so Get must not find it
+	// afterwards; implementations typically delete the key.
+	Set(key string, value T, ttl time.Duration) error
+}
+
+// TieredCache is an in-memory SimpleCache in front of a Backend. Reads check
+// the in-memory cache first and fall through to the backend on a miss, keeping
+// what they find in memory; writes go to both tiers.
+type TieredCache[T any] struct {
+	l1      *SimpleCache[T]
+	backend Backend[T]
+	l1TTL   time.Duration
+}
+
+// NewTieredCache creates a TieredCache serving from l1 and falling back to
+// backend. Values read from backend are kept in l1 for l1TTL.
+func NewTieredCache[T any](l1 *SimpleCache[T], backend Backend[T], l1TTL time.Duration) *TieredCache[T] {
+	return &TieredCache[T]{l1: l1, backend: backend, l1TTL: l1TTL}
+}
+
+// Get returns the value stored under key, from l1 if possible and otherwise
+// from the backend, and whether it was found. Concurrent misses for the same
+// key share a single backend read, as with GetOrLoad. A backend error is
+// returned with found == false.
+func (t *TieredCache[T]) Get(key string) (T, bool, error) {
+	v, err := t.l1.GetOrLoad(key, t.l1TTL, func() (T, error) {
+		v, found, err := t.backend.Get(key)
+		if err == nil && !found {
+			err = ErrNotFound
+		}
+		return v, err
+	})
+	switch {
+	case err == nil:
+		return v, true, nil
+	case errors.Is(err, ErrNotFound):
+		var zero T
+		return zero, false, nil
+	default:
+		var zero T
+		return zero, false, err
+	}
+}
+
+// Set writes value under key to the backend and then to l1, both with ttl. If
+// the backend write fails, l1 is left unchanged and the error is returned.
+func (t *TieredCache[T]) Set(key string, value T, ttl time.Duration) error {
+	if err := t.backend.Set(key, value, ttl); err != nil {
+		return err
+	}
+	t.l1.SetWithTTL(key, value, ttl)
+	return nil
+}
+
+// L1 returns the in-memory cache, e.g. to delete or invalidate entries locally.
+func (t *TieredCache[T]) L1() *SimpleCache[T] {
+	return t.l1
+}
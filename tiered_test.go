@@ -0,0 +1,117 @@
+package keyvalstore
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mapBackend is an in-memory Backend that records how often it is read.
+type mapBackend[T any] struct {
+	mutex sync.Mutex
+	data  map[string]T
+	gets  int
+	err   error
+}
+
+func newMapBackend[T any]() *mapBackend[T] {
+	return &mapBackend[T]{data: make(map[string]T)}
+}
+
+func (b *mapBackend[T]) Get(key string) (T, bool, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.gets++
+	var zero T
+	if b.err != nil {
+		return zero, false, b.err
+	}
+	v, ok := b.data[key]
+	return v, ok, nil
+}
+
+func (b *mapBackend[T]) Set(key string, value T, ttl time.Duration) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.err != nil {
+		return b.err
+	}
+	if ttl <= 0 {
+		delete(b.data, key)
+		return nil
+	}
+	b.data[key] = value
+	return nil
+}
+
+func TestTieredCache_GetFallsThroughAndPopulatesL1(t *testing.T) {
+	l1 := NewSimpleCache[string](0)
+	defer l1.Close()
+	backend := newMapBackend[string]()
+	backend.data["key1"] = "value1"
+	sut := NewTieredCache[string](l1, backend, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		val, found, err := sut.Get("key1")
+		if err != nil || !found || val != "value1" {
+			t.Errorf("Expected to find key1 with value 'value1', got '%s', found: %v, err: %v", val, found, err)
+		}
+	}
+	if backend.gets != 1 {
+		t.Errorf("Expected a single backend read, got %d", backend.gets)
+	}
+	if ttl, found := l1.TTL("key1"); !found || ttl > time.Minute {
+		t.Errorf("Expected key1 to be kept in l1 for the l1 TTL, got %v, found: %v", ttl, found)
+	}
+}
+
+func TestTieredCache_GetMissing(t *testing.T) {
+	l1 := NewSimpleCache[string](0)
+	defer l1.Close()
+	sut := NewTieredCache[string](l1, newMapBackend[string](), time.Minute)
+
+	if val, found, err := sut.Get("missing"); err != nil || found {
+		t.Errorf("Expected a miss without error, got '%s', found: %v, err: %v", val, found, err)
+	}
+	if l1.Has("missing") {
+		t.Errorf("Expected a backend miss not to populate l1")
+	}
+}
+
+func TestTieredCache_GetBackendError(t *testing.T) {
+	l1 := NewSimpleCache[string](0)
+	defer l1.Close()
+	backend := newMapBackend[string]()
+	backend.err = errors.New("connection refused")
+	sut := NewTieredCache[string](l1, backend, time.Minute)
+
+	if _, found, err := sut.Get("key1"); !errors.Is(err, backend.err) || found {
+		t.Errorf("Expected the backend error, got %v, found: %v", err, found)
+	}
+}
+
+func TestTieredCache_SetWritesThrough(t *testing.T) {
+	l1 := NewSimpleCache[string](0)
+	defer l1.Close()
+	backend := newMapBackend[string]()
+	sut := NewTieredCache[string](l1, backend, time.Minute)
+
+	if err := sut.Set("key1", "value1", time.Hour); err != nil {
+		t.Fatalf("Expected Set to succeed, got %v", err)
+	}
+	if backend.data["key1"] != "value1" {
+		t.Errorf("Expected the backend to hold key1")
+	}
+	if val, found := l1.Get("key1"); !found || val != "value1" {
+		t.Errorf("Expected l1 to hold key1, got '%s', found: %v", val, found)
+	}
+
+	backend.err = errors.New("read-only replica")
+	if err := sut.Set("key2", "value2", time.Hour); !errors.Is(err, backend.err) {
+		t.Errorf("Expected the backend error, got %v", err)
+	}
+	if l1.Has("key2") {
+		t.Errorf("Expected a failed backend write to leave l1 unchanged")
+	}
+}
@@ -0,0 +1,65 @@
+package keyvalstore
+
+import (
+	"context"
+	"slices"
+)
+
+// WaitForKey returns the live value stored under key, blocking until one is
+// stored if the key is missing or expired. It returns ctx's error if ctx is
+// done first, and so waits forever with a context that is never done. Use it
+// to hand a value from a producer that stores it to a consumer that needs it.
+// Waiting does not count as a hit or a miss. Once the cache is closed, no value
+// can be stored, so WaitForKey returns ErrClosed instead of waiting.
+func (c *Cache[K, V]) WaitForKey(ctx context.Context, key K) (V, error) {
+	s := c.shardFor(key)
+	for {
+		s.mutex.Lock()
+		if item, exists := s.data[key]; exists && item.live(c.clock.Now()) {
+			s.mutex.Unlock()
+			return c.copyValue(item.value), nil
+		}
+		if c.closed.Load() {
+			s.mutex.Unlock()
+			var zero V
+			return zero, ErrClosed
+		}
+		ready := make(chan struct{})
+		if s.waiters == nil {
+			s.waiters = make(map[K][]chan struct{})
+		}
+		s.waiters[key] = append(s.waiters[key], ready)
+		s.mutex.Unlock()
+
+		select {
+		case <-ready:
+			// The value may already be gone again, so check once more.
+		case <-ctx.Done():
+			s.mutex.Lock()
+			s.unwaitLocked(key, ready)
+			s.mutex.Unlock()
+			var zero V
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// wakeLocked wakes every WaitForKey caller waiting for key. The caller must
+// hold the shard's write lock.
+func (s *shard[K, V]) wakeLocked(key K) {
+	for _, ready := range s.waiters[key] {
+		close(ready)
+	}
+	delete(s.waiters, key)
+}
+
+// unwaitLocked removes a waiter that gave up, unless it was already woken.
+// The caller must hold the shard's write lock.
+func (s *shard[K, V]) unwaitLocked(key K, ready chan struct{}) {
+	waiters := slices.DeleteFunc(s.waiters[key], func(w chan struct{}) bool { return w == ready })
+	if len(waiters) == 0 {
+		delete(s.waiters, key)
+	} else {
+		s.waiters[key] = waiters
+	}
+}
@@ -0,0 +1,73 @@
+package keyvalstore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSimpleCache_WaitForKey(t *testing.T) {
+	sut := NewSimpleCache[string](0)
+	sut.Set("key1", "value1")
+
+	if val, err := sut.WaitForKey(context.Background(), "key1"); err != nil || val != "value1" {
+		t.Errorf("Expected 'value1' at once, got '%s', err: %v", val, err)
+	}
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if val, err := sut.WaitForKey(ctx, "key2"); err != nil || val != "value2" {
+				t.Errorf("Expected 'value2' once stored, got '%s', err: %v", val, err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	sut.Set("key2", "value2")
+	wg.Wait()
+
+	if n := len(sut.shards[0].waiters); n != 0 {
+		t.Errorf("Expected no waiters left, got %d keys", n)
+	}
+}
+
+func TestSimpleCache_WaitForKeyExpired(t *testing.T) {
+	sut := NewSimpleCache[string](0)
+	sut.SetWithTTL("key1", "stale", -time.Second)
+
+	done := make(chan string)
+	go func() {
+		val, _ := sut.WaitForKey(context.Background(), "key1")
+		done <- val
+	}()
+	time.Sleep(10 * time.Millisecond)
+	sut.Set("key1", "fresh")
+
+	select {
+	case val := <-done:
+		if val != "fresh" {
+			t.Errorf("Expected 'fresh', got '%s'", val)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected WaitForKey to return once key1 was stored again")
+	}
+}
+
+func TestSimpleCache_WaitForKeyCancelled(t *testing.T) {
+	sut := NewSimpleCache[string](0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := sut.WaitForKey(ctx, "key1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline error, got %v", err)
+	}
+	if n := len(sut.shards[0].waiters); n != 0 {
+		t.Errorf("Expected a cancelled waiter to be removed, got %d keys", n)
+	}
+}